@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"gopkg.in/yaml.v3"
+)
+
+// labelSpec is a label in the labels file.
+type labelSpec struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	// Aliases are the previous names of the label.
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+// labelUsage is a label with the number of sampled issues it is applied to.
+type labelUsage struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Uses        int    `json:"uses"`
+}
+
+type labelMerge struct {
+	Labels []string `json:"labels"`
+	Into   string   `json:"into"`
+	Reason string   `json:"reason"`
+}
+
+type labelProposal struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Reason      string `json:"reason"`
+}
+
+// labelAdvice is the taxonomy changes proposed by the model.
+type labelAdvice struct {
+	Merges       []labelMerge    `json:"merges"`
+	Missing      []labelProposal `json:"missing"`
+	Unused       []labelProposal `json:"unused"`
+	Descriptions []labelProposal `json:"descriptions"`
+}
+
+func adviseLabelsCommand(args []string) error {
+	fs := flag.NewFlagSet("advise-labels", flag.ExitOnError)
+	repo := fs.String("repo", "", "the repository in the owner/name format (default $GITHUB_REPOSITORY)")
+	output := fs.String("output", "", "the path to write the proposed labels YAML to")
+	sample := fs.Int("sample", 100, "the number of recent issues shown to the model")
+	gptModel := fs.String("gpt-model", openai.GPT3Dot5Turbo, fmt.Sprintf("the chat-gpt model used (default %s)", openai.GPT3Dot5Turbo))
+	maxPromptTokens := fs.Int("max-prompt-tokens", 0, "the maximum number of tokens in the prompt (default is the context window of the model)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var cfg config
+	if err := commandEnv(&cfg, *repo); err != nil {
+		return err
+	}
+	if cfg.gptToken == "" {
+		return errors.New("env \"OPENAI_API_KEY\" is required")
+	}
+
+	ctx := context.Background()
+	ghapi := NewGithubClient(cfg.ghToken, cfg.graphQLEndpoint, nil)
+
+	labels, err := ghapi.FetchRepoLabels(ctx, cfg.repoOwner, cfg.repoName)
+	if err != nil {
+		return err
+	}
+
+	issues, err := fetchRecentIssues(ctx, ghapi, cfg, *sample)
+	if err != nil {
+		return err
+	}
+	log.Printf("Sampled %d issues", len(issues))
+
+	useModelTokenizer(*gptModel)
+	assistant := newLabelingAssistant(cfg.gptToken, *gptModel, nil)
+	assistant.budget = newTokenBudget(*gptModel, *maxPromptTokens)
+
+	advice, err := assistant.AdviseLabels(ctx, countLabelUsage(labels, issues), issues)
+	if err != nil {
+		return err
+	}
+
+	current := specsFromLabels(labels)
+	proposed := advice.apply(current)
+
+	if err := writeLabelsDiff(os.Stdout, advice, current, proposed); err != nil {
+		return err
+	}
+
+	if *output != "" {
+		b, err := marshalLabelSpecs(proposed)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*output, b, 0o644); err != nil {
+			return fmt.Errorf("failed to write labels: %w", err)
+		}
+	}
+	return nil
+}
+
+// fetchRecentIssues pages through the most recent issues until count of them are fetched.
+func fetchRecentIssues(ctx context.Context, ghapi *GitHubGraphQLClient, cfg config, count int) ([]labeledItem, error) {
+	var (
+		issues []labeledItem
+		after  string
+	)
+
+	for len(issues) < count {
+		items, page, err := ghapi.FetchLabeledItems(
+			ctx, cfg.repoOwner, cfg.repoName, kindIssues, min(count-len(issues), 100), after,
+		)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, items...)
+
+		if !page.HasNextPage {
+			break
+		}
+		after = page.EndCursor
+	}
+	return issues, nil
+}
+
+// countLabelUsage counts the issues each label is applied to.
+func countLabelUsage(labels []Label, issues []labeledItem) []labelUsage {
+	uses := make(map[string]int)
+	for _, issue := range issues {
+		for _, l := range issue.Labels {
+			uses[strings.ToLower(l.Name)]++
+		}
+	}
+
+	usage := make([]labelUsage, 0, len(labels))
+	for _, l := range labels {
+		usage = append(usage, labelUsage{
+			Name:        l.Name,
+			Description: l.Description,
+			Uses:        uses[strings.ToLower(l.Name)],
+		})
+	}
+	return usage
+}
+
+// AdviseLabels asks the model to review the labels of the repository against the sampled issues.
+func (a labelingAssistant) AdviseLabels(
+	ctx context.Context, labels []labelUsage, issues []labeledItem,
+) (labelAdvice, error) {
+	systemPrompt := `You are the maintainer reviewing the labels of a GitHub repository.
+You will receive the labels with the number of recent issues they are applied to, followed by the recent issues.
+Propose changes that make the labels easier to apply consistently:
+- merge labels that mean the same thing into one of them;
+- add labels for topics that many issues share but no label covers;
+- remove labels that are unused and not needed;
+- improve descriptions that are missing, vague or do not match how the label is used.
+Propose only the changes you are confident about. Give a short reason for each change.
+
+Provide the answer as json. For example:
+{
+  "merges": [{"labels": ["defect", "bug"], "into": "bug", "reason": "Both mark something that is not working."}],
+  "missing": [{"name": "area/docs", "description": "Documentation", "reason": "Many issues are about the docs."}],
+  "unused": [{"name": "wontfix-later", "reason": "Not applied to any recent issue."}],
+  "descriptions": [{"name": "question", "description": "Usage questions", "reason": "The description is empty."}]
+}
+`
+
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return labelAdvice{}, err
+	}
+
+	// the labels are shown in full, the issues take the rest of the budget
+	content := "Labels:\n" + string(b) + "\n\nIssues:\n\n"
+	examples := make([]fewShotExample, 0, len(issues))
+	for _, issue := range issues {
+		examples = append(examples, fewShotExample{Title: issue.Title, Body: issue.Body, Labels: issue.labelNames()})
+	}
+	content += formatExamples(examples, a.budget.body(systemPrompt)-countTokens(content))
+
+	var advice labelAdvice
+	_, err = a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: truncated(content, a.budget.body(systemPrompt))},
+	}, &advice)
+	if err != nil {
+		return labelAdvice{}, fmt.Errorf("failed to advise labels: %w", err)
+	}
+	return advice, nil
+}
+
+func (i labeledItem) labelNames() []string {
+	names := make([]string, 0, len(i.Labels))
+	for _, l := range i.Labels {
+		names = append(names, l.Name)
+	}
+	return names
+}
+
+func specsFromLabels(labels []Label) []labelSpec {
+	specs := make([]labelSpec, 0, len(labels))
+	for _, l := range labels {
+		specs = append(specs, labelSpec{Name: l.Name, Color: l.Color, Description: l.Description})
+	}
+	sortLabelSpecs(specs)
+	return specs
+}
+
+func sortLabelSpecs(specs []labelSpec) {
+	slices.SortStableFunc(specs, func(a, b labelSpec) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+}
+
+// apply returns the labels changed by the advice. Merged labels become aliases of the label
+// they are merged into: sync-labels renames an alias if the label does not exist yet and,
+// with -delete, moves the items of the alias to the label otherwise.
+// Changes of labels that do not exist are ignored.
+func (a labelAdvice) apply(current []labelSpec) []labelSpec {
+	proposed := slices.Clone(current)
+	find := func(name string) int {
+		return slices.IndexFunc(proposed, func(s labelSpec) bool {
+			return strings.EqualFold(s.Name, name)
+		})
+	}
+
+	for _, d := range a.Descriptions {
+		if i := find(d.Name); i >= 0 {
+			proposed[i].Description = d.Description
+		} else {
+			log.Printf("Ignoring the description of unknown label %q", d.Name)
+		}
+	}
+
+	for _, m := range a.Merges {
+		into := find(m.Into)
+		if into < 0 {
+			log.Printf("Ignoring the merge into unknown label %q", m.Into)
+			continue
+		}
+		for _, name := range m.Labels {
+			i := find(name)
+			if i < 0 || i == into {
+				continue
+			}
+			proposed[into].Aliases = append(proposed[into].Aliases, proposed[i].Name)
+			proposed[into].Aliases = append(proposed[into].Aliases, proposed[i].Aliases...)
+			proposed = slices.Delete(proposed, i, i+1)
+			if i < into {
+				into--
+			}
+		}
+	}
+
+	for _, u := range a.Unused {
+		if i := find(u.Name); i >= 0 {
+			proposed = slices.Delete(proposed, i, i+1)
+		} else {
+			log.Printf("Ignoring the removal of unknown label %q", u.Name)
+		}
+	}
+
+	for _, m := range a.Missing {
+		if find(m.Name) >= 0 {
+			continue
+		}
+		proposed = append(proposed, labelSpec{Name: m.Name, Description: m.Description})
+	}
+
+	sortLabelSpecs(proposed)
+	return proposed
+}
+
+func marshalLabelSpecs(specs []labelSpec) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(specs); err != nil {
+		return nil, fmt.Errorf("failed to encode labels: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode labels: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeLabelsDiff writes the reasons for the changes as YAML comments followed by
+// the unified diff between the current and the proposed labels.
+func writeLabelsDiff(w io.Writer, advice labelAdvice, current, proposed []labelSpec) error {
+	before, err := marshalLabelSpecs(current)
+	if err != nil {
+		return err
+	}
+	after, err := marshalLabelSpecs(proposed)
+	if err != nil {
+		return err
+	}
+
+	var out strings.Builder
+	for _, m := range advice.Merges {
+		fmt.Fprintf(&out, "# merge %s into %s: %s\n", strings.Join(m.Labels, ", "), m.Into, m.Reason)
+	}
+	for _, m := range advice.Missing {
+		fmt.Fprintf(&out, "# add %s: %s\n", m.Name, m.Reason)
+	}
+	for _, u := range advice.Unused {
+		fmt.Fprintf(&out, "# remove %s: %s\n", u.Name, u.Reason)
+	}
+	for _, d := range advice.Descriptions {
+		fmt.Fprintf(&out, "# describe %s: %s\n", d.Name, d.Reason)
+	}
+
+	out.WriteString("--- labels (current)\n+++ labels (proposed)\n")
+	for _, line := range diffLines(splitLines(string(before)), splitLines(string(after))) {
+		out.WriteString(line + "\n")
+	}
+
+	_, err = io.WriteString(w, out.String())
+	return err
+}
+
+func splitLines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the lines of both texts prefixed with "-" if removed,
+// "+" if added and " " if kept, based on their longest common subsequence.
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var (
+		lines []string
+		i, j  int
+	)
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, "+"+b[j])
+			j++
+		default:
+			lines = append(lines, "-"+a[i])
+			i++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountLabelUsage(t *testing.T) {
+	labels := []Label{{Name: "bug", Description: "Broken"}, {Name: "question"}}
+	issues := []labeledItem{
+		{Labels: []appliedLabel{{Name: "bug"}}},
+		{Labels: []appliedLabel{{Name: "Bug"}, {Name: "area/cli"}}},
+	}
+
+	expected := []labelUsage{
+		{Name: "bug", Description: "Broken", Uses: 2},
+		{Name: "question", Uses: 0},
+	}
+	assert.Equal(t, expected, countLabelUsage(labels, issues))
+}
+
+func TestAdviseLabels(t *testing.T) {
+	assistant := fakeAssistant(t, `{"merges":[{"labels":["defect","bug"],"into":"bug","reason":"Same."}],"unused":[{"name":"old","reason":"Unused."}]}`)
+
+	advice, err := assistant.AdviseLabels(context.TODO(),
+		[]labelUsage{{Name: "bug", Uses: 1}, {Name: "defect"}, {Name: "old"}},
+		[]labeledItem{{Title: "Crash", Body: "It crashes.", Labels: []appliedLabel{{Name: "bug"}}}},
+	)
+	require.NoError(t, err)
+
+	expected := labelAdvice{
+		Merges: []labelMerge{{Labels: []string{"defect", "bug"}, Into: "bug", Reason: "Same."}},
+		Unused: []labelProposal{{Name: "old", Reason: "Unused."}},
+	}
+	assert.Equal(t, expected, advice)
+}
+
+func TestLabelAdviceApply(t *testing.T) {
+	current := []labelSpec{
+		{Name: "bug", Color: "d73a4a", Description: "Broken"},
+		{Name: "defect", Color: "ff0000", Aliases: []string{"broken"}},
+		{Name: "old"},
+		{Name: "question"},
+	}
+
+	advice := labelAdvice{
+		Merges:       []labelMerge{{Labels: []string{"defect", "bug"}, Into: "bug"}, {Labels: []string{"x"}, Into: "missing"}},
+		Missing:      []labelProposal{{Name: "area/docs", Description: "Documentation"}, {Name: "Question"}},
+		Unused:       []labelProposal{{Name: "old"}, {Name: "unknown"}},
+		Descriptions: []labelProposal{{Name: "question", Description: "Usage questions"}, {Name: "unknown"}},
+	}
+
+	expected := []labelSpec{
+		{Name: "area/docs", Description: "Documentation"},
+		{Name: "bug", Color: "d73a4a", Description: "Broken", Aliases: []string{"defect", "broken"}},
+		{Name: "question", Description: "Usage questions"},
+	}
+	assert.Equal(t, expected, advice.apply(current))
+	assert.Len(t, current, 4, "the current labels are not changed")
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        []string
+		b        []string
+		expected []string
+	}{
+		{
+			name:     "equal",
+			a:        []string{"a", "b"},
+			b:        []string{"a", "b"},
+			expected: []string{" a", " b"},
+		},
+		{
+			name:     "changed",
+			a:        []string{"a", "b", "c"},
+			b:        []string{"a", "x", "c", "d"},
+			expected: []string{" a", "-b", "+x", " c", "+d"},
+		},
+		{
+			name:     "removed",
+			a:        []string{"a", "b"},
+			b:        nil,
+			expected: []string{"-a", "-b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, diffLines(tt.a, tt.b))
+		})
+	}
+}
+
+func TestWriteLabelsDiff(t *testing.T) {
+	current := []labelSpec{{Name: "bug", Color: "d73a4a"}, {Name: "old"}}
+	advice := labelAdvice{
+		Unused:       []labelProposal{{Name: "old", Reason: "Unused."}},
+		Descriptions: []labelProposal{{Name: "bug", Description: "Broken", Reason: "Empty."}},
+	}
+
+	var out strings.Builder
+	require.NoError(t, writeLabelsDiff(&out, advice, current, advice.apply(current)))
+
+	expected := `# remove old: Unused.
+# describe bug: Empty.
+--- labels (current)
++++ labels (proposed)
+ - name: bug
+   color: d73a4a
+-- name: old
++  description: Broken
+`
+	assert.Equal(t, expected, out.String())
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// author is the author of the labeled item.
+type author struct {
+	login string
+	// association is the relation to the repository, e.g. "OWNER", "MEMBER", "CONTRIBUTOR" or "NONE"
+	association string
+	isBot       bool
+}
+
+func matchLogin(login string, patterns []string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(login))
+		return err == nil && matched
+	})
+}
+
+func matchAssociation(association string, associations []string) bool {
+	return slices.ContainsFunc(associations, func(a string) bool {
+		return strings.EqualFold(a, association)
+	})
+}
+
+// skipReason returns why the items of the author are not labeled, or an empty string.
+func (c fileConfig) skipReason(a author) string {
+	switch {
+	case c.SkipBots && a.isBot:
+		return fmt.Sprintf("the author %q is a bot", a.login)
+	case matchLogin(a.login, c.SkipAuthors):
+		return fmt.Sprintf("the author %q is skipped", a.login)
+	case matchAssociation(a.association, c.SkipAssociations):
+		return fmt.Sprintf("the author association %q is skipped", a.association)
+	case len(c.OnlyAssociations) > 0 && !matchAssociation(a.association, c.OnlyAssociations):
+		return fmt.Sprintf("the author association %q is not in %s", a.association, strings.Join(c.OnlyAssociations, ", "))
+	default:
+		return ""
+	}
+}
+
+// matches reports whether the rule applies to the author.
+// A rule without authors and associations applies to nobody.
+func (r authorRule) matches(a author) bool {
+	return matchLogin(a.login, r.Authors) || matchAssociation(a.association, r.Associations)
+}
+
+// applyAuthorRules returns the details and the excluded labels extended by the matching rules.
+func (c fileConfig) applyAuthorRules(a author, details string, excluded []string) (string, []string) {
+	for _, r := range c.AuthorRules {
+		if !r.matches(a) {
+			continue
+		}
+		if r.Details != "" {
+			details = strings.TrimSpace(details + "\n" + r.Details)
+		}
+		excluded = append(slices.Clip(excluded), r.ExcludedLabels...)
+	}
+	return details, excluded
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipReason(t *testing.T) {
+	cfg := fileConfig{
+		SkipBots:         true,
+		SkipAuthors:      []string{"renovate*", "spammer"},
+		SkipAssociations: []string{"OWNER"},
+		OnlyAssociations: []string{"NONE", "CONTRIBUTOR", "OWNER"},
+	}
+
+	tests := []struct {
+		name   string
+		author author
+		skip   bool
+	}{
+		{name: "bot", author: author{login: "dependabot[bot]", isBot: true}, skip: true},
+		{name: "pattern", author: author{login: "renovate[bot]", association: "NONE"}, skip: true},
+		{name: "login is case insensitive", author: author{login: "Spammer", association: "NONE"}, skip: true},
+		{name: "skipped association", author: author{login: "owner", association: "OWNER"}, skip: true},
+		{name: "not in only associations", author: author{login: "member", association: "MEMBER"}, skip: true},
+		{name: "external contributor", author: author{login: "user", association: "CONTRIBUTOR"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := cfg.skipReason(tt.author)
+			if tt.skip {
+				assert.NotEmpty(t, reason)
+			} else {
+				assert.Empty(t, reason)
+			}
+		})
+	}
+
+	assert.Empty(t, fileConfig{}.skipReason(author{login: "dependabot[bot]", isBot: true}))
+}
+
+func TestApplyAuthorRules(t *testing.T) {
+	cfg := fileConfig{
+		AuthorRules: []authorRule{
+			{Associations: []string{"first_time_contributor"}, Details: "Be welcoming.", ExcludedLabels: []string{"wontfix"}},
+			{Authors: []string{"*[bot]"}, Details: "Dependency updates."},
+		},
+	}
+
+	details, excluded := cfg.applyAuthorRules(
+		author{login: "newcomer", association: "FIRST_TIME_CONTRIBUTOR"}, "Base details.", []string{"duplicate"},
+	)
+	assert.Equal(t, "Base details.\nBe welcoming.", details)
+	assert.Equal(t, []string{"duplicate", "wontfix"}, excluded)
+
+	details, excluded = cfg.applyAuthorRules(author{login: "maintainer", association: "MEMBER"}, "", nil)
+	assert.Empty(t, details)
+	assert.Empty(t, excluded)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	storeFile = "file"
+	storeRepo = "repo"
+
+	defaultCorrectionsPath = ".auto-label/corrections.jsonl"
+)
+
+// correction is a label change made by a human on an item labeled by the action.
+type correction struct {
+	Kind   string `json:"kind"`
+	Number int    `json:"number,omitempty"`
+	NodeID string `json:"node_id"`
+	Title  string `json:"title"`
+	// Action is "labeled" or "unlabeled".
+	Action string `json:"action"`
+	Label  string `json:"label"`
+	Actor  string `json:"actor"`
+	// BotLabels are the labels chosen by the action.
+	BotLabels []string  `json:"bot_labels"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// mistake describes the correction for the prompt, or returns an empty string
+// if the correction does not contradict the choice of the action.
+func (c correction) mistake() string {
+	chosen := slices.Contains(c.BotLabels, c.Label)
+	switch {
+	case c.Action == "unlabeled" && chosen:
+		return fmt.Sprintf("- %q: the label %q was chosen, but a maintainer removed it.", c.Title, c.Label)
+	case c.Action == "labeled" && !chosen:
+		return fmt.Sprintf("- %q: the label %q was not chosen, but a maintainer added it.", c.Title, c.Label)
+	default:
+		return ""
+	}
+}
+
+// formatMistakes renders the corrections that contradict the choices of the action.
+func formatMistakes(corrections []correction) string {
+	var lines []string
+	for _, c := range corrections {
+		if m := c.mistake(); m != "" {
+			lines = append(lines, m)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// correctionStore persists corrections between runs.
+type correctionStore interface {
+	Append(ctx context.Context, c correction) error
+	// Recent returns up to n latest corrections.
+	Recent(ctx context.Context, n int) ([]correction, error)
+}
+
+func newCorrectionStore(cfg config, ghapi *GitHubGraphQLClient) (correctionStore, error) {
+	switch cfg.correctionsStore {
+	case storeFile:
+		return fileCorrectionStore{path: cfg.correctionsPath}, nil
+	case storeRepo:
+		return repoCorrectionStore{ghapi: ghapi, owner: cfg.repoOwner, repo: cfg.repoName, path: cfg.correctionsPath}, nil
+	default:
+		return nil, fmt.Errorf("unknown corrections store %q", cfg.correctionsStore)
+	}
+}
+
+// fileCorrectionStore keeps corrections in a local JSONL file,
+// which can be uploaded as a workflow artifact or kept by a long-running process.
+type fileCorrectionStore struct {
+	path string
+}
+
+func (s fileCorrectionStore) Append(_ context.Context, c correction) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create corrections dir: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open corrections: %w", err)
+	}
+	defer f.Close()
+
+	return writeJSONL(f, []correction{c})
+}
+
+func (s fileCorrectionStore) Recent(_ context.Context, n int) ([]correction, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read corrections: %w", err)
+	}
+	return lastCorrections(b, n)
+}
+
+// repoCorrectionStore keeps corrections in a JSONL file committed to the default branch of the repository.
+type repoCorrectionStore struct {
+	ghapi *GitHubGraphQLClient
+	owner string
+	repo  string
+	path  string
+}
+
+// correctionCommitAttempts is how many times a correction is committed before giving up.
+// Concurrent events move the branch between fetching the file and committing it.
+const correctionCommitAttempts = 3
+
+func (s repoCorrectionStore) Append(ctx context.Context, c correction) error {
+	headline := fmt.Sprintf("Record label correction for #%d", c.Number)
+
+	var err error
+	for attempt := 1; attempt <= correctionCommitAttempts; attempt++ {
+		var file repoFile
+		if file, err = s.ghapi.FetchFile(ctx, s.owner, s.repo, s.path); err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString(file.Text)
+		if err := writeJSONL(&buf, []correction{c}); err != nil {
+			return err
+		}
+
+		if err = s.ghapi.CommitFile(ctx, s.owner, s.repo, file, s.path, buf.Bytes(), headline); err == nil {
+			return nil
+		} else if !isCommitConflict(err) {
+			return err
+		}
+		log.Printf("Attempt %d to record the correction failed: %v", attempt, err)
+	}
+	return err
+}
+
+// isCommitConflict reports whether the commit failed because the branch moved after the file was fetched.
+func isCommitConflict(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "expected branch to point to") || strings.Contains(msg, "stale")
+}
+
+func (s repoCorrectionStore) Recent(ctx context.Context, n int) ([]correction, error) {
+	file, err := s.ghapi.FetchFile(ctx, s.owner, s.repo, s.path)
+	if err != nil {
+		return nil, err
+	}
+	return lastCorrections([]byte(file.Text), n)
+}
+
+func lastCorrections(b []byte, n int) ([]correction, error) {
+	corrections, err := readJSONL[correction](bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode corrections: %w", err)
+	}
+	return corrections[max(len(corrections)-n, 0):], nil
+}
+
+// recordCorrection stores the label change if a human made it on an item labeled by the action.
+func recordCorrection(ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, p payload) error {
+	if cfg.correctionsStore == "" || p.label == "" {
+		return nil
+	}
+
+	if isBotActor(p.sender, resolveBotLogin(ctx, ghapi)) {
+		log.Println("The label was changed by a bot.")
+		return nil
+	}
+
+	previous, err := ghapi.FetchBotComment(ctx, p.nodeID)
+	if err != nil {
+		return err
+	}
+
+	if previous == nil {
+		log.Println("The item was not labeled by the action.")
+		return nil
+	}
+
+	store, err := newCorrectionStore(cfg, ghapi)
+	if err != nil {
+		return err
+	}
+
+	c := correction{
+		Kind:      objectName(cfg.eventName),
+		Number:    p.number,
+		NodeID:    p.nodeID,
+		Title:     p.title,
+		Action:    p.action,
+		Label:     p.label,
+		Actor:     p.sender,
+		BotLabels: previous.Marker.Labels,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := store.Append(ctx, c); err != nil {
+		return err
+	}
+
+	log.Printf("Recorded the label %q %s by %s.", c.Label, c.Action, c.Actor)
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrectionMistake(t *testing.T) {
+	tests := []struct {
+		name       string
+		correction correction
+		expected   string
+	}{
+		{
+			name:       "removed chosen label",
+			correction: correction{Title: "Crash", Action: "unlabeled", Label: "question", BotLabels: []string{"question"}},
+			expected:   `- "Crash": the label "question" was chosen, but a maintainer removed it.`,
+		},
+		{
+			name:       "added missed label",
+			correction: correction{Title: "Crash", Action: "labeled", Label: "bug", BotLabels: []string{"question"}},
+			expected:   `- "Crash": the label "bug" was not chosen, but a maintainer added it.`,
+		},
+		{
+			name:       "added chosen label back",
+			correction: correction{Title: "Crash", Action: "labeled", Label: "bug", BotLabels: []string{"bug"}},
+		},
+		{
+			name:       "removed unrelated label",
+			correction: correction{Title: "Crash", Action: "unlabeled", Label: "triage", BotLabels: []string{"bug"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.correction.mistake())
+		})
+	}
+}
+
+func TestFormatMistakes(t *testing.T) {
+	assert.Empty(t, formatMistakes(nil))
+
+	corrections := []correction{
+		{Title: "A", Action: "unlabeled", Label: "bug", BotLabels: []string{"bug"}},
+		{Title: "B", Action: "labeled", Label: "bug", BotLabels: []string{"bug"}},
+		{Title: "C", Action: "labeled", Label: "docs"},
+	}
+	assert.Equal(t,
+		"- \"A\": the label \"bug\" was chosen, but a maintainer removed it.\n"+
+			"- \"C\": the label \"docs\" was not chosen, but a maintainer added it.\n",
+		formatMistakes(corrections),
+	)
+}
+
+func TestFileCorrectionStore(t *testing.T) {
+	store := fileCorrectionStore{path: filepath.Join(t.TempDir(), "dir", "corrections.jsonl")}
+
+	recent, err := store.Recent(context.TODO(), 2)
+	require.NoError(t, err)
+	assert.Empty(t, recent)
+
+	for _, label := range []string{"a", "b", "c"} {
+		require.NoError(t, store.Append(context.TODO(), correction{Action: "labeled", Label: label}))
+	}
+
+	recent, err = store.Recent(context.TODO(), 2)
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.Equal(t, "b", recent[0].Label)
+	assert.Equal(t, "c", recent[1].Label)
+}
+
+func TestRepoCorrectionStoreAppendRetries(t *testing.T) {
+	transport := &sequenceTransport{
+		responses: []string{
+			`{"data":{"repository":{"defaultBranchRef":{"name":"main","target":{"oid":"abc"}},"object":null}}}`,
+			`{"errors":[{"message":"Expected branch to point to \"abc\" but it did not."}]}`,
+			`{"data":{"repository":{"defaultBranchRef":{"name":"main","target":{"oid":"def"}},"object":{"text":"{}\n"}}}}`,
+			`{"data":{"createCommitOnBranch":{"commit":{"oid":"ghi"}}}}`,
+		},
+	}
+	store := repoCorrectionStore{
+		ghapi: NewGithubClient("token", "url", &http.Client{Transport: transport}),
+		owner: "owner",
+		repo:  "repo",
+		path:  "corrections.jsonl",
+	}
+
+	require.NoError(t, store.Append(context.TODO(), correction{Number: 1, Action: "labeled", Label: "bug"}))
+	require.Len(t, transport.requests, 4)
+	assert.Contains(t, transport.requests[3], `"expectedHeadOid":"def"`)
+}
+
+func TestRepoCorrectionStoreAppendDoesNotRetryOtherErrors(t *testing.T) {
+	transport := &sequenceTransport{
+		responses: []string{
+			`{"data":{"repository":{"defaultBranchRef":{"name":"main","target":{"oid":"abc"}},"object":null}}}`,
+			`{"errors":[{"message":"Resource not accessible by integration"}]}`,
+		},
+	}
+	store := repoCorrectionStore{
+		ghapi: NewGithubClient("token", "url", &http.Client{Transport: transport}),
+		owner: "owner",
+		repo:  "repo",
+		path:  "corrections.jsonl",
+	}
+
+	require.ErrorContains(t, store.Append(context.TODO(), correction{Number: 1, Action: "labeled", Label: "bug"}), "Resource not accessible")
+	require.Len(t, transport.requests, 2)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// datasetRecord is a labeled item of an evaluation dataset, one JSON object per line.
+type datasetRecord struct {
+	Title  string   `json:"title"`
+	Body   string   `json:"body"`
+	Labels []string `json:"labels"`
+}
+
+func (r datasetRecord) payload() payload {
+	return payload{title: r.Title, body: r.Body}
+}
+
+func readDataset(r io.Reader) ([]datasetRecord, error) {
+	return readJSONL[datasetRecord](r)
+}
+
+// readJSONL decodes one JSON value per line skipping empty lines.
+func readJSONL[T any](r io.Reader) ([]T, error) {
+	var records []T
+
+	scanner := bufio.NewScanner(r)
+	// issue bodies with pasted logs easily exceed the default line limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var record T
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("failed to decode record on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	return records, nil
+}
+
+func writeJSONL[T any](w io.Writer, records []T) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+	}
+	return nil
+}
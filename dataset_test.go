@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDataset(t *testing.T) {
+	t.Run("happy", func(t *testing.T) {
+		input := `{"title":"Crash","body":"It crashes","labels":["bug"]}
+
+{"title":"Docs","labels":["documentation","good first issue"]}
+`
+		records, err := readDataset(strings.NewReader(input))
+		require.NoError(t, err)
+
+		expected := []datasetRecord{
+			{Title: "Crash", Body: "It crashes", Labels: []string{"bug"}},
+			{Title: "Docs", Labels: []string{"documentation", "good first issue"}},
+		}
+		assert.Equal(t, expected, records)
+	})
+
+	t.Run("invalid record", func(t *testing.T) {
+		_, err := readDataset(strings.NewReader("{\"title\":\"Crash\"}\nnot json\n"))
+		require.Error(t, err)
+		assert.ErrorContains(t, err, "line 2")
+	})
+}
+
+func TestWriteDataset(t *testing.T) {
+	records := []datasetRecord{
+		{Title: "Crash", Body: "It crashes", Labels: []string{"bug"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeJSONL(&buf, records))
+
+	read, err := readDataset(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, records, read)
+}
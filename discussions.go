@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultCategoryConfidence = 0.8
+	defaultQuestionConfidence = 0.9
+	defaultQACategory         = "Q&A"
+)
+
+type discussionCategory struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	IsAnswerable bool   `json:"isAnswerable"`
+}
+
+func findCategory(categories []discussionCategory, name string) (discussionCategory, bool) {
+	idx := slices.IndexFunc(categories, func(c discussionCategory) bool {
+		return strings.EqualFold(c.Name, name)
+	})
+	if idx < 0 {
+		return discussionCategory{}, false
+	}
+	return categories[idx], true
+}
+
+// categoryVerdict is the discussion category chosen by the model.
+type categoryVerdict struct {
+	Category    string  `json:"category"`
+	Confidence  float64 `json:"confidence"`
+	Explanation string  `json:"explanation"`
+}
+
+// ChooseCategory asks the model which of the categories fits the discussion.
+func (a labelingAssistant) ChooseCategory(
+	ctx context.Context, payload string, categories []discussionCategory,
+) (categoryVerdict, error) {
+	systemPrompt := `You are the developer moderating discussions on GitHub.
+You will receive a discussion. Choose the category that fits it best.
+
+Categories:
+`
+	for _, c := range categories {
+		systemPrompt += fmt.Sprintf("- %s: %s\n", c.Name, c.Description)
+	}
+	systemPrompt += `
+Provide the answer as json. For example:
+{
+  "category": "Ideas",
+  "confidence": 0.9,
+  "explanation": "The author proposes a new feature."
+}
+
+The "category" field is the name of one of the categories and "confidence" is a number from 0 to 1.
+`
+
+	var verdict categoryVerdict
+	_, err := a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: truncated(payload, a.budget.body(systemPrompt))},
+	}, &verdict)
+	if err != nil {
+		return categoryVerdict{}, fmt.Errorf("failed to choose category: %w", err)
+	}
+	return verdict, nil
+}
+
+// moveDiscussion moves the discussion to the category chosen by the model
+// and returns the comment section explaining the move.
+func moveDiscussion(ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, assistant *labelingAssistant, p payload) (string, error) {
+	_, categories, err := ghapi.FetchDiscussionCategories(ctx, cfg.repoOwner, cfg.repoName)
+	if err != nil {
+		return "", err
+	}
+
+	verdict, err := assistant.ChooseCategory(ctx, p.String(), categories)
+	if err != nil {
+		return "", err
+	}
+
+	category, ok := findCategory(categories, verdict.Category)
+	switch {
+	case !ok:
+		log.Printf("The model chose an unknown category %q.", verdict.Category)
+		return "", nil
+	case verdict.Confidence < cfg.categoryConfidence:
+		log.Printf("The category %q is not confident enough (%.2f).", category.Name, verdict.Confidence)
+		return "", nil
+	case strings.EqualFold(category.Name, p.category):
+		return "", nil
+	}
+
+	if err := ghapi.UpdateDiscussionCategory(ctx, p.nodeID, category.ID); err != nil {
+		return "", err
+	}
+	log.Printf("The discussion is moved to %q.", category.Name)
+
+	return fmt.Sprintf("**Category:** moved from %q to %q — %s\n", p.category, category.Name, verdict.Explanation), nil
+}
+
+// questionVerdict is the answer of the model about whether the issue is a question.
+type questionVerdict struct {
+	Question    bool    `json:"question"`
+	Confidence  float64 `json:"confidence"`
+	Explanation string  `json:"explanation"`
+}
+
+// DetectQuestion asks the model whether the issue is a usage question rather than a bug or a request.
+func (a labelingAssistant) DetectQuestion(ctx context.Context, payload string) (questionVerdict, error) {
+	systemPrompt := `You are the developer triaging issues on GitHub.
+You will receive an issue. Decide whether it is a usage question that is better answered in a Q&A discussion
+rather than a bug report, a feature request or another task that needs changes in the project.
+
+Provide the answer as json. For example:
+{
+  "question": true,
+  "confidence": 0.95,
+  "explanation": "The author asks how to configure the tool."
+}
+
+The "confidence" field is a number from 0 to 1.
+`
+
+	var verdict questionVerdict
+	_, err := a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: truncated(payload, a.budget.body(systemPrompt))},
+	}, &verdict)
+	if err != nil {
+		return questionVerdict{}, fmt.Errorf("failed to detect question: %w", err)
+	}
+	return verdict, nil
+}
+
+// convertQuestion reports whether the issue is a question and, if so, moves it to a Q&A discussion.
+// The API cannot convert issues, so the discussion is created with the content of the issue,
+// and the issue is closed with a link to it.
+func convertQuestion(
+	ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, assistant *labelingAssistant, p payload, dryRun bool,
+) (bool, error) {
+	verdict, err := assistant.DetectQuestion(ctx, p.String())
+	if err != nil {
+		return false, err
+	}
+	if !verdict.Question || verdict.Confidence < cfg.questionConfidence {
+		return false, nil
+	}
+
+	log.Printf("The issue is a question (confidence %.2f): %s", verdict.Confidence, verdict.Explanation)
+	if dryRun || cfg.mode != modeApply {
+		log.Println("The question is not moved to a discussion.")
+		return false, nil
+	}
+
+	repoID, categories, err := ghapi.FetchDiscussionCategories(ctx, cfg.repoOwner, cfg.repoName)
+	if err != nil {
+		return false, err
+	}
+
+	category, ok := findCategory(categories, cfg.qaCategory)
+	if !ok {
+		log.Printf("The discussion category %q does not exist.", cfg.qaCategory)
+		return false, nil
+	}
+
+	body := fmt.Sprintf("%s\n\n---\n_Moved from #%d opened by @%s._", p.body, p.number, p.author.login)
+	url, err := ghapi.CreateDiscussion(ctx, repoID, category.ID, p.title, body)
+	if err != nil {
+		return false, err
+	}
+
+	comment := fmt.Sprintf("This looks like a question, so it was moved to a discussion where the community can answer it: %s\n\n%s",
+		url, verdict.Explanation)
+	// the discussion exists already, so the issue counts as converted even if it is left open
+	if err := ghapi.AddComment(ctx, p.nodeID, strconv.Quote(comment)); err != nil {
+		return true, err
+	}
+
+	if err := ghapi.CloseIssue(ctx, p.nodeID); err != nil {
+		return true, err
+	}
+	log.Printf("The question is moved to %s.", url)
+	return true, nil
+}
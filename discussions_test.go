@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const categoriesResponse = `{"data":{"repository":{"id":"R_1","discussionCategories":{"nodes":[
+	{"id":"C_1","name":"General","description":"Anything"},
+	{"id":"C_2","name":"Q&A","description":"Ask the community","isAnswerable":true},
+	{"id":"C_3","name":"Ideas","description":"Feature ideas"}
+]}}}}`
+
+func TestMoveDiscussion(t *testing.T) {
+	cfg := config{eventName: "discussion", repoOwner: "owner", repoName: "repo", categoryConfidence: 0.8}
+	p := payload{nodeID: "D_1", title: "Add dark mode", category: "General"}
+
+	t.Run("move", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{categoriesResponse, `{"data":{}}`}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		assistant := fakeAssistant(t, `{"category":"Ideas","confidence":0.9,"explanation":"A feature idea."}`)
+
+		section, err := moveDiscussion(context.TODO(), cfg, ghapi, assistant, p)
+		require.NoError(t, err)
+		assert.Equal(t, "**Category:** moved from \"General\" to \"Ideas\" — A feature idea.\n", section)
+		require.Len(t, transport.requests, 2)
+		assert.Contains(t, transport.requests[1], "updateDiscussion")
+		assert.Contains(t, transport.requests[1], `"categoryId":"C_3"`)
+	})
+
+	tests := []struct {
+		name   string
+		answer string
+	}{
+		{name: "not confident", answer: `{"category":"Ideas","confidence":0.5}`},
+		{name: "same category", answer: `{"category":"general","confidence":0.9}`},
+		{name: "unknown category", answer: `{"category":"Show and tell","confidence":0.9}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &sequenceTransport{responses: []string{categoriesResponse}}
+			ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+			section, err := moveDiscussion(context.TODO(), cfg, ghapi, fakeAssistant(t, tt.answer), p)
+			require.NoError(t, err)
+			assert.Empty(t, section)
+			assert.Equal(t, 1, transport.calls)
+		})
+	}
+}
+
+func TestConvertQuestion(t *testing.T) {
+	cfg := config{
+		eventName: "issues", repoOwner: "owner", repoName: "repo",
+		mode: modeApply, questionConfidence: 0.9, qaCategory: "Q&A",
+	}
+	p := payload{nodeID: "I_1", number: 5, title: "How to configure?", body: "Help", author: author{login: "user"}}
+	question := `{"question":true,"confidence":0.95,"explanation":"A usage question."}`
+
+	t.Run("convert", func(t *testing.T) {
+		transport := &sequenceTransport{
+			responses: []string{
+				categoriesResponse,
+				`{"data":{"createDiscussion":{"discussion":{"url":"https://github.com/owner/repo/discussions/9"}}}}`,
+				`{"data":{}}`,
+			},
+		}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		converted, err := convertQuestion(context.TODO(), cfg, ghapi, fakeAssistant(t, question), p, false)
+		require.NoError(t, err)
+		assert.True(t, converted)
+		require.Len(t, transport.requests, 4)
+		assert.Contains(t, transport.requests[1], `"categoryId":"C_2"`)
+		assert.Contains(t, transport.requests[1], `"repositoryId":"R_1"`)
+		assert.Contains(t, transport.requests[1], "Moved from #5 opened by @user.")
+		assert.Contains(t, transport.requests[2], "https://github.com/owner/repo/discussions/9")
+		assert.Contains(t, transport.requests[3], "closeIssue")
+	})
+
+	t.Run("issue left open", func(t *testing.T) {
+		transport := &sequenceTransport{
+			responses: []string{
+				categoriesResponse,
+				`{"data":{"createDiscussion":{"discussion":{"url":"https://github.com/owner/repo/discussions/9"}}}}`,
+				`{"errors":[{"message":"Resource not accessible by integration"}]}`,
+			},
+		}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		// the discussion is created, so the issue must not be labeled as well
+		converted, err := convertQuestion(context.TODO(), cfg, ghapi, fakeAssistant(t, question), p, false)
+		require.Error(t, err)
+		assert.True(t, converted)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{`{"data":{}}`}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		converted, err := convertQuestion(context.TODO(), cfg, ghapi, fakeAssistant(t, question), p, true)
+		require.NoError(t, err)
+		assert.False(t, converted)
+		assert.Zero(t, transport.calls)
+	})
+
+	t.Run("not confident", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{`{"data":{}}`}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		answer := `{"question":true,"confidence":0.6}`
+		converted, err := convertQuestion(context.TODO(), cfg, ghapi, fakeAssistant(t, answer), p, false)
+		require.NoError(t, err)
+		assert.False(t, converted)
+		assert.Zero(t, transport.calls)
+	})
+}
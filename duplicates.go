@@ -0,0 +1,147 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultDuplicateLabel      = "duplicate"
+	defaultDuplicateConfidence = 0.8
+
+	// duplicateSearchTerms is the number of title keywords the existing issues are searched by.
+	duplicateSearchTerms = 4
+	// duplicateSearchResults is the number of issues requested from the search.
+	duplicateSearchResults = 20
+	// duplicateCandidates is the number of issues shown to the model.
+	duplicateCandidates = 5
+)
+
+// duplicateVerdict is the answer of the model about possible duplicates.
+type duplicateVerdict struct {
+	// DuplicateOf is the number of the duplicated issue or zero.
+	DuplicateOf int     `json:"duplicate_of"`
+	Confidence  float64 `json:"confidence"`
+	Explanation string  `json:"explanation"`
+}
+
+// duplicateSearchQuery builds a search query for issues with the most specific words of the title.
+func duplicateSearchQuery(owner, repo, title string) string {
+	var terms []string
+	for w := range keywords(title) {
+		terms = append(terms, w)
+	}
+
+	// longer words are usually more specific
+	slices.SortFunc(terms, func(a, b string) int {
+		if len(a) != len(b) {
+			return cmp.Compare(len(b), len(a))
+		}
+		return strings.Compare(a, b)
+	})
+
+	query := fmt.Sprintf("repo:%s/%s is:issue", owner, repo)
+	for _, t := range terms[:min(duplicateSearchTerms, len(terms))] {
+		query += " " + t
+	}
+	return query
+}
+
+func (i issueSummary) text() string {
+	return fmt.Sprintf("Title: %s\nBody: %s", i.Title, i.Body)
+}
+
+// rankIssues returns up to count issues most similar to the text by embeddings.
+// If embed is nil, the order of the search is kept.
+func rankIssues(ctx context.Context, embed embedFunc, issues []issueSummary, text string, count int) ([]issueSummary, error) {
+	if embed == nil || len(issues) == 0 {
+		return issues[:min(count, len(issues))], nil
+	}
+
+	input := []string{truncated(text, embeddingInputTokens)}
+	for _, i := range issues {
+		input = append(input, truncated(i.text(), embeddingInputTokens))
+	}
+
+	embeddings, err := embed(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed issues: %w", err)
+	}
+
+	scores := make(map[string]float64, len(issues))
+	for n, i := range issues {
+		scores[i.ID] = cosineSimilarity(embeddings[0], embeddings[n+1])
+	}
+
+	ranked := slices.Clone(issues)
+	slices.SortStableFunc(ranked, func(a, b issueSummary) int {
+		return cmp.Compare(scores[b.ID], scores[a.ID])
+	})
+	return ranked[:min(count, len(ranked))], nil
+}
+
+// FindDuplicate asks the model whether the item duplicates one of the issues.
+func (a labelingAssistant) FindDuplicate(ctx context.Context, payload string, issues []issueSummary) (duplicateVerdict, error) {
+	systemPrompt := `You are the developer triaging issues on GitHub.
+You will receive a new issue followed by existing issues of the same repository.
+Decide whether the new issue reports the same problem or requests the same change as one of the existing issues.
+Similar topics are not enough: the issues must be about the same thing.
+
+Provide the answer as json. For example:
+{
+  "duplicate_of": 42,
+  "confidence": 0.9,
+  "explanation": "Both issues report the same crash on startup."
+}
+
+The "duplicate_of" field is the number of the duplicated issue or 0 if there is none,
+"confidence" is a number from 0 to 1.
+`
+
+	content := "New issue:\n" + truncated(payload, a.budget.total/2) + "\n\nExisting issues:\n"
+	for _, i := range issues {
+		content += fmt.Sprintf("\nNumber: %d\nState: %s\nTitle: %s\nBody: %s\n", i.Number, i.State, i.Title, truncated(i.Body, exampleBodyTokens))
+	}
+
+	var verdict duplicateVerdict
+	_, err := a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: content},
+	}, &verdict)
+	if err != nil {
+		return duplicateVerdict{}, fmt.Errorf("failed to find duplicate: %w", err)
+	}
+	return verdict, nil
+}
+
+// duplicateOf returns the duplicated issue if the model is confident enough.
+func (v duplicateVerdict) duplicateOf(issues []issueSummary, threshold float64) (issueSummary, bool) {
+	if v.DuplicateOf == 0 || v.Confidence < threshold {
+		return issueSummary{}, false
+	}
+
+	idx := slices.IndexFunc(issues, func(i issueSummary) bool {
+		return i.Number == v.DuplicateOf
+	})
+	if idx < 0 {
+		return issueSummary{}, false
+	}
+	return issues[idx], true
+}
+
+// formatDuplicates renders the comment section with the duplicated issue and the other candidates.
+func formatDuplicates(v duplicateVerdict, original issueSummary, candidates []issueSummary) string {
+	section := fmt.Sprintf("**Possible duplicate** of #%d (confidence %.0f%%): %s\n",
+		original.Number, v.Confidence*100, v.Explanation)
+
+	section += "\nSimilar issues:\n"
+	for _, i := range candidates {
+		section += fmt.Sprintf("- [#%d %s](%s)\n", i.Number, i.Title, i.URL)
+	}
+	return section
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateSearchQuery(t *testing.T) {
+	assert.Equal(t,
+		"repo:owner/repo is:issue segfault startup parser crash",
+		duplicateSearchQuery("owner", "repo", "The parser has a crash on startup: segfault in it"),
+	)
+	assert.Equal(t, "repo:owner/repo is:issue", duplicateSearchQuery("owner", "repo", "a b"))
+}
+
+func TestRankIssues(t *testing.T) {
+	issues := []issueSummary{
+		{ID: "1", Number: 1, Title: "Improve docs"},
+		{ID: "2", Number: 2, Title: "Crash on start"},
+		{ID: "3", Number: 3, Title: "CI is red"},
+	}
+
+	t.Run("search order", func(t *testing.T) {
+		ranked, err := rankIssues(context.TODO(), nil, issues, "Title: Crash", 2)
+		require.NoError(t, err)
+		assert.Equal(t, issues[:2], ranked)
+	})
+
+	t.Run("embeddings", func(t *testing.T) {
+		var calls [][]string
+		ranked, err := rankIssues(context.TODO(), fakeEmbed(&calls), issues, "Title: Crash", 1)
+		require.NoError(t, err)
+		require.Len(t, ranked, 1)
+		assert.Equal(t, 2, ranked[0].Number)
+		assert.Len(t, calls, 1)
+	})
+}
+
+func TestDuplicateOf(t *testing.T) {
+	issues := []issueSummary{{Number: 1}, {Number: 2}}
+
+	tests := []struct {
+		name     string
+		verdict  duplicateVerdict
+		expected int
+	}{
+		{name: "confident", verdict: duplicateVerdict{DuplicateOf: 2, Confidence: 0.9}, expected: 2},
+		{name: "not confident", verdict: duplicateVerdict{DuplicateOf: 2, Confidence: 0.5}},
+		{name: "no duplicate", verdict: duplicateVerdict{Confidence: 0.9}},
+		{name: "unknown issue", verdict: duplicateVerdict{DuplicateOf: 3, Confidence: 0.9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, ok := tt.verdict.duplicateOf(issues, 0.8)
+			assert.Equal(t, tt.expected != 0, ok)
+			assert.Equal(t, tt.expected, original.Number)
+		})
+	}
+}
+
+func TestFormatDuplicates(t *testing.T) {
+	candidates := []issueSummary{
+		{Number: 1, Title: "Crash", URL: "https://github.com/owner/repo/issues/1"},
+		{Number: 2, Title: "Panic", URL: "https://github.com/owner/repo/issues/2"},
+	}
+	verdict := duplicateVerdict{DuplicateOf: 1, Confidence: 0.9, Explanation: "Same crash."}
+
+	assert.Equal(t,
+		"**Possible duplicate** of #1 (confidence 90%): Same crash.\n\nSimilar issues:\n"+
+			"- [#1 Crash](https://github.com/owner/repo/issues/1)\n"+
+			"- [#2 Panic](https://github.com/owner/repo/issues/2)\n",
+		formatDuplicates(verdict, candidates[0], candidates),
+	)
+}
+
+func TestFindDuplicate(t *testing.T) {
+	assistant := fakeAssistant(t, `{"duplicate_of":1,"confidence":0.9,"explanation":"Same crash."}`)
+
+	verdict, err := assistant.FindDuplicate(context.TODO(), "Title: Crash", []issueSummary{{Number: 1, Title: "Crash"}})
+	require.NoError(t, err)
+	assert.Equal(t, duplicateVerdict{DuplicateOf: 1, Confidence: 0.9, Explanation: "Same crash."}, verdict)
+}
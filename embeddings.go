@@ -0,0 +1,208 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	embeddingModel = openai.AdaEmbeddingV2
+
+	// embeddingInputTokens is the maximum number of tokens in a single embedding input.
+	embeddingInputTokens = 8000
+)
+
+type embedFunc func(ctx context.Context, input []string) ([][]float32, error)
+
+func (a labelingAssistant) Embed(ctx context.Context, input []string) ([][]float32, error) {
+	resp, err := a.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: input,
+		Model: embeddingModel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+
+	if a.embeddingTokens != nil {
+		*a.embeddingTokens += resp.Usage.TotalTokens
+	}
+
+	if len(resp.Data) != len(input) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(input), len(resp.Data))
+	}
+
+	embeddings := make([][]float32, len(input))
+	for _, e := range resp.Data {
+		if e.Index < 0 || e.Index >= len(input) {
+			return nil, fmt.Errorf("unexpected embedding index %d", e.Index)
+		}
+		embeddings[e.Index] = e.Embedding
+	}
+	return embeddings, nil
+}
+
+// embeddingCache stores embeddings by the hash of the embedded content,
+// so a label is embedded again only when its name or description changes.
+type embeddingCache map[string][]float32
+
+func loadEmbeddingCache(path string) (embeddingCache, error) {
+	cache := make(embeddingCache)
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read embedding cache: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding cache: %w", err)
+	}
+	return cache, nil
+}
+
+func (c embeddingCache) save(path string) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode embedding cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create embedding cache dir: %w", err)
+	}
+
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write embedding cache: %w", err)
+	}
+	return nil
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(embeddingModel.String() + "\n" + content))
+	return hex.EncodeToString(sum[:])
+}
+
+func labelContent(l Label) string {
+	if l.Description == "" {
+		return l.Name
+	}
+	return l.Name + ": " + l.Description
+}
+
+// embedLabels returns the embeddings of the labels in the same order,
+// requesting only those that are missing from the cache.
+func embedLabels(ctx context.Context, embed embedFunc, labels []Label, cache embeddingCache) ([][]float32, error) {
+	contents := make([]string, 0, len(labels))
+	for _, l := range labels {
+		contents = append(contents, labelContent(l))
+	}
+	return embedCached(ctx, embed, contents, cache)
+}
+
+// embedCached returns the embeddings of the contents in the same order,
+// requesting only those that are missing from the cache.
+func embedCached(ctx context.Context, embed embedFunc, contents []string, cache embeddingCache) ([][]float32, error) {
+	var missing []string
+	for _, content := range contents {
+		if _, exist := cache[contentHash(content)]; !exist && !slices.Contains(missing, content) {
+			missing = append(missing, content)
+		}
+	}
+
+	if len(missing) > 0 {
+		embeddings, err := embed(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for i, content := range missing {
+			cache[contentHash(content)] = embeddings[i]
+		}
+	}
+
+	embeddings := make([][]float32, 0, len(contents))
+	for _, content := range contents {
+		embeddings = append(embeddings, cache[contentHash(content)])
+	}
+	return embeddings, nil
+}
+
+// preselectLabels returns the topK labels most similar to the text
+// and the labels that must always be offered to the model.
+func preselectLabels(
+	ctx context.Context, embed embedFunc, labels []Label, text string,
+	topK int, alwaysIncluded []string, cache embeddingCache,
+) ([]Label, error) {
+	if len(labels) <= topK {
+		return labels, nil
+	}
+
+	labelEmbeddings, err := embedLabels(ctx, embed, labels, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	input, _ := truncateText(text, embeddingInputTokens)
+	textEmbeddings, err := embed(ctx, []string{input})
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		label Label
+		score float64
+	}
+
+	var candidates []scored
+	for i, l := range labels {
+		candidates = append(candidates, scored{
+			label: l,
+			score: cosineSimilarity(textEmbeddings[0], labelEmbeddings[i]),
+		})
+	}
+
+	slices.SortStableFunc(candidates, func(a, b scored) int {
+		return cmp.Compare(b.score, a.score)
+	})
+
+	var selected []Label
+	for i, c := range candidates {
+		always := slices.ContainsFunc(alwaysIncluded, func(s string) bool {
+			return strings.EqualFold(c.label.Name, s)
+		})
+		if i < topK || always {
+			selected = append(selected, c.label)
+		}
+	}
+	return selected, nil
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbed maps each input to a vector by the keywords it contains.
+func fakeEmbed(calls *[][]string) embedFunc {
+	keywords := []string{"crash", "docs", "ci", "question"}
+	return func(_ context.Context, input []string) ([][]float32, error) {
+		*calls = append(*calls, input)
+		var embeddings [][]float32
+		for _, s := range input {
+			v := make([]float32, len(keywords))
+			for i, k := range keywords {
+				if strings.Contains(strings.ToLower(s), k) {
+					v[i] = 1
+				}
+			}
+			embeddings = append(embeddings, v)
+		}
+		return embeddings, nil
+	}
+}
+
+func TestPreselectLabels(t *testing.T) {
+	labels := []Label{
+		{ID: "1", Name: "bug", Description: "Crash or wrong behavior"},
+		{ID: "2", Name: "documentation", Description: "Docs improvements"},
+		{ID: "3", Name: "ci", Description: "Continuous integration"},
+		{ID: "4", Name: "question", Description: "Further information is requested"},
+	}
+
+	t.Run("top-k with always included", func(t *testing.T) {
+		var calls [][]string
+		cache := make(embeddingCache)
+
+		selected, err := preselectLabels(
+			context.TODO(), fakeEmbed(&calls), labels, "Title: App crash\nBody: It crashes",
+			1, []string{"question"}, cache,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []Label{labels[0], labels[3]}, selected)
+		assert.Len(t, cache, len(labels))
+	})
+
+	t.Run("cached labels are not embedded again", func(t *testing.T) {
+		var calls [][]string
+		cache := make(embeddingCache)
+		embed := fakeEmbed(&calls)
+
+		_, err := preselectLabels(context.TODO(), embed, labels, "docs", 2, nil, cache)
+		require.NoError(t, err)
+
+		_, err = preselectLabels(context.TODO(), embed, labels, "ci", 2, nil, cache)
+		require.NoError(t, err)
+
+		require.Len(t, calls, 3)
+		assert.Len(t, calls[0], len(labels))
+		assert.Equal(t, []string{"ci"}, calls[2])
+	})
+
+	t.Run("fewer labels than top-k", func(t *testing.T) {
+		var calls [][]string
+		selected, err := preselectLabels(context.TODO(), fakeEmbed(&calls), labels, "docs", 10, nil, make(embeddingCache))
+		require.NoError(t, err)
+		assert.Equal(t, labels, selected)
+		assert.Empty(t, calls)
+	})
+}
+
+func TestEmbeddingCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "embeddings.json")
+
+	cache, err := loadEmbeddingCache(path)
+	require.NoError(t, err)
+	assert.Empty(t, cache)
+
+	cache[contentHash("bug")] = []float32{1, 0}
+	require.NoError(t, cache.save(path))
+
+	loaded, err := loadEmbeddingCache(path)
+	require.NoError(t, err)
+	assert.Equal(t, cache, loaded)
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func evalCommand(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	applyFlags := registerFlags(fs)
+	datasetPath := fs.String("dataset", "", "the path to a JSONL dataset with the title, body and expected labels of each item")
+	fromRepo := fs.Int("from-repo", 0, "if no dataset is given, evaluate on this many recently closed issues labeled by maintainers")
+	repo := fs.String("repo", "", "the repository in the owner/name format (default $GITHUB_REPOSITORY)")
+	reportPath := fs.String("report", "", "the path to write the JSON report to")
+	datasetOutput := fs.String("dataset-output", "", "the path to write the dataset sampled with -from-repo to, for later runs with -dataset")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var cfg config
+	applyFlags(&cfg)
+	if err := commandEnv(&cfg, *repo); err != nil {
+		return err
+	}
+	if cfg.gptToken == "" {
+		return errors.New("env \"OPENAI_API_KEY\" is required")
+	}
+	if err := validateSimilarity(cfg.fewShotBy); err != nil {
+		return err
+	}
+
+	useModelTokenizer(cfg.gptModel)
+	ghapi := NewGithubClient(cfg.ghToken, cfg.graphQLEndpoint, nil)
+
+	ctx := context.Background()
+
+	var (
+		records []datasetRecord
+		err     error
+	)
+	switch {
+	case *datasetPath != "":
+		records, err = readDatasetFile(*datasetPath)
+	case *fromRepo > 0:
+		records, err = datasetFromRepo(ctx, ghapi, cfg, *fromRepo)
+	default:
+		return errors.New("either -dataset or -from-repo is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	if *fromRepo > 0 && *datasetOutput != "" {
+		if err := writeDatasetFile(*datasetOutput, records); err != nil {
+			return err
+		}
+	}
+
+	availableLabels, err := ghapi.FetchRepoLabels(ctx, cfg.repoOwner, cfg.repoName)
+	if err != nil {
+		return err
+	}
+	availableLabels = filterLabels(availableLabels, cfg.excludedLabels)
+
+	results := evaluate(ctx, cfg, newLabeler(cfg, ghapi), availableLabels, records)
+	report := buildReport(cfg.gptModel, results)
+
+	if err := report.writeTable(os.Stdout); err != nil {
+		return err
+	}
+
+	if *reportPath != "" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		if err := os.WriteFile(*reportPath, b, 0o644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func readDatasetFile(path string) ([]datasetRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+
+	return readDataset(f)
+}
+
+func writeDatasetFile(path string, records []datasetRecord) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create dataset: %w", err)
+	}
+	defer f.Close()
+
+	return writeJSONL(f, records)
+}
+
+func datasetFromRepo(ctx context.Context, ghapi *GitHubGraphQLClient, cfg config, count int) ([]datasetRecord, error) {
+	items, err := ghapi.FetchClosedIssues(ctx, cfg.repoOwner, cfg.repoName, count)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []datasetRecord
+	for _, e := range examplesFromItems(items, resolveBotLogin(ctx, ghapi)) {
+		records = append(records, datasetRecord{Title: e.Title, Body: e.Body, Labels: e.Labels})
+	}
+	return records, nil
+}
+
+type evalResult struct {
+	expected  []string
+	predicted []string
+	latency   time.Duration
+	cost      float64
+	err       error
+}
+
+func evaluate(
+	ctx context.Context, cfg config, l *labeler, availableLabels []Label, records []datasetRecord,
+) []evalResult {
+	var results []evalResult
+
+	for i, record := range records {
+		result := evalResult{
+			expected: knownLabels(record.Labels, availableLabels),
+		}
+
+		embedded := *l.assistant.embeddingTokens
+		itemCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.timeout)*time.Second)
+		start := time.Now()
+		resp, err := l.classify(itemCtx, availableLabels, record.payload())
+		result.latency = time.Since(start)
+		cancel()
+
+		if err != nil {
+			log.Printf("Item %d %q failed: %s", i+1, record.Title, err)
+			result.err = err
+		}
+
+		for _, label := range resp.Labels {
+			result.predicted = append(result.predicted, label.Name)
+		}
+		result.cost = cost(cfg.gptModel, resp.usage) + embeddingCost(*l.assistant.embeddingTokens-embedded)
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// knownLabels keeps only the expected labels that the model can choose from.
+func knownLabels(names []string, available []Label) []string {
+	var known []string
+	for _, name := range names {
+		if slices.ContainsFunc(available, func(l Label) bool {
+			return strings.EqualFold(l.Name, name)
+		}) {
+			known = append(known, name)
+		}
+	}
+	return known
+}
+
+type labelMetrics struct {
+	Label          string  `json:"label"`
+	Support        int     `json:"support"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+}
+
+func (m *labelMetrics) compute() {
+	m.Precision = ratio(m.TruePositives, m.TruePositives+m.FalsePositives)
+	m.Recall = ratio(m.TruePositives, m.TruePositives+m.FalseNegatives)
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+}
+
+type latencyStats struct {
+	MeanMs int64 `json:"mean_ms"`
+	P50Ms  int64 `json:"p50_ms"`
+	P95Ms  int64 `json:"p95_ms"`
+	MaxMs  int64 `json:"max_ms"`
+}
+
+type evalReport struct {
+	Model      string         `json:"model"`
+	Items      int            `json:"items"`
+	Failed     int            `json:"failed"`
+	ExactMatch float64        `json:"exact_match"`
+	Overall    labelMetrics   `json:"overall"`
+	Labels     []labelMetrics `json:"labels"`
+	CostUSD    float64        `json:"cost_usd"`
+	Latency    latencyStats   `json:"latency"`
+}
+
+func buildReport(model string, results []evalResult) evalReport {
+	report := evalReport{
+		Model:   model,
+		Items:   len(results),
+		Overall: labelMetrics{Label: "(overall)"},
+	}
+
+	metrics := make(map[string]*labelMetrics)
+	get := func(label string) *labelMetrics {
+		key := strings.ToLower(label)
+		if _, exist := metrics[key]; !exist {
+			metrics[key] = &labelMetrics{Label: label}
+		}
+		return metrics[key]
+	}
+
+	var (
+		exact     int
+		latencies []time.Duration
+	)
+
+	for _, r := range results {
+		report.CostUSD += r.cost
+		latencies = append(latencies, r.latency)
+
+		if r.err != nil {
+			report.Failed++
+			continue
+		}
+
+		expected := lowerSet(r.expected)
+		predicted := lowerSet(r.predicted)
+
+		for _, label := range r.expected {
+			m := get(label)
+			m.Support++
+			if _, ok := predicted[strings.ToLower(label)]; ok {
+				m.TruePositives++
+			} else {
+				m.FalseNegatives++
+			}
+		}
+
+		for _, label := range r.predicted {
+			if _, ok := expected[strings.ToLower(label)]; !ok {
+				get(label).FalsePositives++
+			}
+		}
+
+		if setsEqual(expected, predicted) {
+			exact++
+		}
+	}
+
+	for _, m := range metrics {
+		m.compute()
+		report.Labels = append(report.Labels, *m)
+
+		report.Overall.Support += m.Support
+		report.Overall.TruePositives += m.TruePositives
+		report.Overall.FalsePositives += m.FalsePositives
+		report.Overall.FalseNegatives += m.FalseNegatives
+	}
+	report.Overall.compute()
+
+	slices.SortFunc(report.Labels, func(a, b labelMetrics) int {
+		return strings.Compare(a.Label, b.Label)
+	})
+
+	report.ExactMatch = ratio(exact, report.Items-report.Failed)
+	report.Latency = computeLatency(latencies)
+
+	return report
+}
+
+func (r evalReport) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "LABEL\tSUPPORT\tTP\tFP\tFN\tPRECISION\tRECALL\tF1\t")
+	for _, m := range append(r.Labels, r.Overall) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.3f\t%.3f\t%.3f\t\n",
+			m.Label, m.Support, m.TruePositives, m.FalsePositives, m.FalseNegatives, m.Precision, m.Recall, m.F1)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\nModel: %s\nItems: %d (failed: %d)\nExact match: %.3f\nCost: $%.4f\nLatency: mean %dms, p50 %dms, p95 %dms, max %dms\n",
+		r.Model, r.Items, r.Failed, r.ExactMatch, r.CostUSD,
+		r.Latency.MeanMs, r.Latency.P50Ms, r.Latency.P95Ms, r.Latency.MaxMs)
+	return err
+}
+
+func computeLatency(latencies []time.Duration) latencyStats {
+	if len(latencies) == 0 {
+		return latencyStats{}
+	}
+
+	slices.Sort(latencies)
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+
+	percentile := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(latencies)))) - 1
+		return latencies[max(i, 0)]
+	}
+
+	return latencyStats{
+		MeanMs: (total / time.Duration(len(latencies))).Milliseconds(),
+		P50Ms:  percentile(0.5).Milliseconds(),
+		P95Ms:  percentile(0.95).Milliseconds(),
+		MaxMs:  latencies[len(latencies)-1].Milliseconds(),
+	}
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+func lowerSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = struct{}{}
+	}
+	return set
+}
+
+func setsEqual(a, b map[string]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReport(t *testing.T) {
+	results := []evalResult{
+		{expected: []string{"bug"}, predicted: []string{"bug"}, latency: 100 * time.Millisecond, cost: 0.01},
+		{expected: []string{"bug", "ui"}, predicted: []string{"Bug", "question"}, latency: 200 * time.Millisecond, cost: 0.02},
+		{expected: []string{"question"}, latency: 300 * time.Millisecond, err: errors.New("timeout")},
+	}
+
+	report := buildReport("gpt-4", results)
+
+	assert.Equal(t, "gpt-4", report.Model)
+	assert.Equal(t, 3, report.Items)
+	assert.Equal(t, 1, report.Failed)
+	assert.InDelta(t, 0.5, report.ExactMatch, 1e-9)
+	assert.InDelta(t, 0.03, report.CostUSD, 1e-9)
+	assert.Equal(t, latencyStats{MeanMs: 200, P50Ms: 200, P95Ms: 300, MaxMs: 300}, report.Latency)
+
+	expected := []labelMetrics{
+		{Label: "bug", Support: 2, TruePositives: 2, Precision: 1, Recall: 1, F1: 1},
+		{Label: "question", FalsePositives: 1},
+		{Label: "ui", Support: 1, FalseNegatives: 1},
+	}
+	assert.Equal(t, expected, report.Labels)
+
+	assert.Equal(t, 3, report.Overall.Support)
+	assert.InDelta(t, 2.0/3, report.Overall.Precision, 1e-9)
+	assert.InDelta(t, 2.0/3, report.Overall.Recall, 1e-9)
+}
+
+func TestWriteTable(t *testing.T) {
+	report := buildReport("gpt-4", []evalResult{
+		{expected: []string{"bug"}, predicted: []string{"bug"}, latency: time.Second},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, report.writeTable(&buf))
+
+	out := buf.String()
+	assert.Contains(t, out, "PRECISION")
+	assert.Contains(t, out, "(overall)")
+	assert.Contains(t, out, "Exact match: 1.000")
+	assert.Contains(t, out, "Latency: mean 1000ms")
+}
+
+func TestKnownLabels(t *testing.T) {
+	available := []Label{{Name: "bug"}, {Name: "enhancement"}}
+	assert.Equal(t, []string{"Bug"}, knownLabels([]string{"Bug", "duplicate"}, available))
+}
+
+func TestWriteDatasetFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dataset.jsonl")
+	records := []datasetRecord{{Title: "Crash", Body: "It crashes", Labels: []string{"bug"}}}
+
+	require.NoError(t, writeDatasetFile(path, records))
+
+	read, err := readDatasetFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, records, read)
+}
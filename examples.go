@@ -0,0 +1,202 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	similarityKeyword   = "keyword"
+	similarityEmbedding = "embedding"
+
+	// exampleBodyTokens is the maximum number of tokens of a single example body.
+	exampleBodyTokens = 300
+)
+
+// fewShotExample is a previously labeled item shown to the model as a sample of the repository conventions.
+type fewShotExample struct {
+	Title  string
+	Body   string
+	Labels []string
+}
+
+func (e fewShotExample) text() string {
+	return fmt.Sprintf("Title: %s\nBody: %s", e.Title, e.Body)
+}
+
+// isBotActor reports whether the login belongs to this action or to another bot.
+func isBotActor(login, botLogin string) bool {
+	return login == "" || strings.EqualFold(login, botLogin) || strings.HasSuffix(login, "[bot]")
+}
+
+// humanLabels returns the labels of the item that were applied by humans.
+func (i labeledItem) humanLabels(botLogin string) []string {
+	var labels []string
+	for _, l := range i.Labels {
+		if !isBotActor(l.Actor, botLogin) {
+			labels = append(labels, l.Name)
+		}
+	}
+	return labels
+}
+
+// examplesFromItems turns items into examples keeping only the labels applied by humans.
+func examplesFromItems(items []labeledItem, botLogin string) []fewShotExample {
+	var examples []fewShotExample
+	for _, item := range items {
+		labels := item.humanLabels(botLogin)
+		if len(labels) == 0 {
+			continue
+		}
+		examples = append(examples, fewShotExample{
+			Title:  item.Title,
+			Body:   item.Body,
+			Labels: labels,
+		})
+	}
+	return examples
+}
+
+// restrictExamples returns the examples with only the labels among the candidates.
+// The examples left without labels are dropped.
+func restrictExamples(examples []fewShotExample, candidates []Label) []fewShotExample {
+	var restricted []fewShotExample
+	for _, e := range examples {
+		labels := slices.DeleteFunc(slices.Clone(e.Labels), func(name string) bool {
+			return !slices.ContainsFunc(candidates, func(l Label) bool { return strings.EqualFold(l.Name, name) })
+		})
+		if len(labels) == 0 {
+			continue
+		}
+		e.Labels = labels
+		restricted = append(restricted, e)
+	}
+	return restricted
+}
+
+// validateSimilarity checks the way similar examples are found.
+func validateSimilarity(similarity string) error {
+	if similarity != similarityKeyword && similarity != similarityEmbedding {
+		return fmt.Errorf("unknown few-shot similarity %q, expected %q or %q", similarity, similarityKeyword, similarityEmbedding)
+	}
+	return nil
+}
+
+// selectExamples returns up to count examples most similar to the text.
+// If embed is nil, the similarity is computed by shared keywords.
+// The embeddings of the examples are taken from the cache when possible.
+func selectExamples(
+	ctx context.Context, embed embedFunc, cache embeddingCache, examples []fewShotExample, text string, count int,
+) ([]fewShotExample, error) {
+	if len(examples) == 0 || count <= 0 {
+		return nil, nil
+	}
+
+	scores := make([]float64, len(examples))
+
+	if embed != nil {
+		contents := make([]string, 0, len(examples))
+		for _, e := range examples {
+			contents = append(contents, truncated(e.text(), embeddingInputTokens))
+		}
+
+		exampleEmbeddings, err := embedCached(ctx, embed, contents, cache)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed examples: %w", err)
+		}
+
+		textEmbeddings, err := embed(ctx, []string{truncated(text, embeddingInputTokens)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed text: %w", err)
+		}
+
+		for i := range examples {
+			scores[i] = cosineSimilarity(textEmbeddings[0], exampleEmbeddings[i])
+		}
+	} else {
+		words := keywords(text)
+		for i, e := range examples {
+			scores[i] = jaccard(words, keywords(e.text()))
+		}
+	}
+
+	indexes := make([]int, len(examples))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	slices.SortStableFunc(indexes, func(a, b int) int {
+		return cmp.Compare(scores[b], scores[a])
+	})
+
+	var selected []fewShotExample
+	for _, i := range indexes[:min(count, len(indexes))] {
+		selected = append(selected, examples[i])
+	}
+	return selected, nil
+}
+
+func truncated(text string, budget int) string {
+	text, _ = truncateText(text, budget)
+	return text
+}
+
+// formatExamples renders the examples for the prompt without exceeding the budget.
+func formatExamples(examples []fewShotExample, budget int) string {
+	var (
+		out  string
+		used int
+	)
+
+	for _, e := range examples {
+		example := fmt.Sprintf(
+			"Title: %s\nBody: %s\nLabels: %s\n\n",
+			e.Title, truncated(e.Body, exampleBodyTokens), strings.Join(e.Labels, ", "),
+		)
+
+		n := countTokens(example)
+		if used+n > budget {
+			break
+		}
+		out += example
+		used += n
+	}
+	return out
+}
+
+var stopWords = map[string]struct{}{
+	"the": {}, "and": {}, "for": {}, "with": {}, "this": {}, "that": {}, "from": {},
+	"are": {}, "was": {}, "when": {}, "not": {}, "but": {}, "have": {}, "has": {},
+	"title": {}, "body": {},
+}
+
+func keywords(text string) map[string]struct{} {
+	words := make(map[string]struct{})
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if _, stop := stopWords[w]; len([]rune(w)) < 3 || stop {
+			continue
+		}
+		words[w] = struct{}{}
+	}
+	return words
+}
+
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	var common int
+	for w := range a {
+		if _, ok := b[w]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
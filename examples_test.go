@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExamplesFromItems(t *testing.T) {
+	items := []labeledItem{
+		{
+			Title: "Crash on start",
+			Labels: []appliedLabel{
+				{Name: "bug", Actor: "maintainer"},
+				{Name: "triage", Actor: "github-actions[bot]"},
+			},
+		},
+		{
+			Title:  "Labeled by the bot only",
+			Labels: []appliedLabel{{Name: "question", Actor: "auto-labeler"}},
+		},
+	}
+
+	expected := []fewShotExample{
+		{Title: "Crash on start", Labels: []string{"bug"}},
+	}
+	assert.Equal(t, expected, examplesFromItems(items, "auto-labeler"))
+}
+
+func TestSelectExamples(t *testing.T) {
+	examples := []fewShotExample{
+		{Title: "Update documentation for inputs", Labels: []string{"documentation"}},
+		{Title: "Application crashes on startup", Body: "panic: nil pointer", Labels: []string{"bug"}},
+		{Title: "How to configure timeout?", Labels: []string{"question"}},
+	}
+
+	t.Run("keyword", func(t *testing.T) {
+		selected, err := selectExamples(context.TODO(), nil, nil, examples, "Title: Crashes on startup\nBody: panic", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []fewShotExample{examples[1]}, selected)
+	})
+
+	t.Run("embedding", func(t *testing.T) {
+		var calls [][]string
+		cache := make(embeddingCache)
+		selected, err := selectExamples(context.TODO(), fakeEmbed(&calls), cache, examples, "Question about a crash", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []fewShotExample{examples[1]}, selected)
+		assert.Len(t, cache, 3)
+
+		// the examples are embedded once, only the text is embedded again
+		_, err = selectExamples(context.TODO(), fakeEmbed(&calls), cache, examples, "Question about docs", 1)
+		require.NoError(t, err)
+		require.Len(t, calls, 3)
+		assert.Equal(t, []string{"Question about docs"}, calls[2])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		selected, err := selectExamples(context.TODO(), nil, nil, examples, "text", 0)
+		require.NoError(t, err)
+		assert.Empty(t, selected)
+	})
+}
+
+func TestRestrictExamples(t *testing.T) {
+	examples := []fewShotExample{
+		{Title: "Crash", Labels: []string{"bug", "priority/high"}},
+		{Title: "Fast", Labels: []string{"priority/low"}},
+	}
+
+	restricted := restrictExamples(examples, []Label{{Name: "Bug"}, {Name: "question"}})
+	assert.Equal(t, []fewShotExample{{Title: "Crash", Labels: []string{"bug"}}}, restricted)
+	assert.Equal(t, []string{"bug", "priority/high"}, examples[0].Labels, "the examples are not changed")
+}
+
+func TestValidateSimilarity(t *testing.T) {
+	require.NoError(t, validateSimilarity(similarityKeyword))
+	require.NoError(t, validateSimilarity(similarityEmbedding))
+	require.Error(t, validateSimilarity("semantic"))
+}
+
+func TestFormatExamples(t *testing.T) {
+	examples := []fewShotExample{
+		{Title: "Crash", Body: "It crashes", Labels: []string{"bug", "p1"}},
+		{Title: "Docs", Body: "Typo", Labels: []string{"documentation"}},
+	}
+
+	assert.Equal(t, "Title: Crash\nBody: It crashes\nLabels: bug, p1\n\n", formatExamples(examples, 20))
+	assert.Empty(t, formatExamples(examples, 1))
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const exportPageSize = 50
+
+// exportRecord is a dataset record with the provenance of the item and its labels.
+type exportRecord struct {
+	datasetRecord
+	Kind        itemKind       `json:"kind"`
+	Number      int            `json:"number"`
+	URL         string         `json:"url"`
+	CreatedAt   time.Time      `json:"created_at"`
+	LabelEvents []appliedLabel `json:"label_events"`
+}
+
+var exportKinds = map[string]itemKind{
+	"issues":        kindIssues,
+	"pull_requests": kindPullRequests,
+	"discussions":   kindDiscussions,
+}
+
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	repo := fs.String("repo", "", "the repository in the owner/name format (default $GITHUB_REPOSITORY)")
+	output := fs.String("output", "", "the path to write the JSONL dataset to (default stdout)")
+	kinds := fs.String("kinds", "issues,pull_requests,discussions", "a comma-separated list of item kinds to export")
+	limit := fs.Int("limit", 0, "the maximum number of items of each kind (default all)")
+	botLogin := fs.String("bot-login", "", "the login of the labeling bot whose labels are excluded (default is the token owner)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var cfg config
+	if err := commandEnv(&cfg, *repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	ghapi := NewGithubClient(cfg.ghToken, cfg.graphQLEndpoint, nil)
+
+	if *botLogin == "" {
+		*botLogin = resolveBotLogin(ctx, ghapi)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	for _, name := range strings.Split(*kinds, ",") {
+		kind, exist := exportKinds[strings.TrimSpace(name)]
+		if !exist {
+			return fmt.Errorf("unknown item kind %q", name)
+		}
+
+		n, err := exportItems(ctx, ghapi, cfg, kind, *limit, *botLogin, w)
+		if err != nil {
+			return err
+		}
+		log.Printf("Exported %d %s", n, kind)
+	}
+
+	return nil
+}
+
+// exportItems pages through the items of the kind and writes those with labels.
+func exportItems(
+	ctx context.Context, ghapi *GitHubGraphQLClient, cfg config, kind itemKind, limit int, botLogin string, w io.Writer,
+) (int, error) {
+	var (
+		exported int
+		after    string
+	)
+
+	for {
+		items, page, err := ghapi.FetchLabeledItems(ctx, cfg.repoOwner, cfg.repoName, kind, exportPageSize, after)
+		if err != nil {
+			return exported, err
+		}
+
+		var records []exportRecord
+		for _, item := range items {
+			if limit > 0 && exported+len(records) >= limit {
+				break
+			}
+			if record, ok := newExportRecord(kind, item, botLogin); ok {
+				records = append(records, record)
+			}
+		}
+
+		if err := writeJSONL(w, records); err != nil {
+			return exported, err
+		}
+		exported += len(records)
+
+		if !page.HasNextPage || (limit > 0 && exported >= limit) {
+			return exported, nil
+		}
+		after = page.EndCursor
+	}
+}
+
+// newExportRecord converts the item dropping the labels applied by bots or by unknown actors.
+// It reports false if no labels are left or if it is unknown which labels of a discussion the bot applied.
+func newExportRecord(kind itemKind, item labeledItem, botLogin string) (exportRecord, bool) {
+	// discussions have no timeline, so none of their labels have an actor
+	var botLabels []string
+	if kind == kindDiscussions {
+		var known bool
+		if botLabels, known = item.markerLabels(botLogin); !known {
+			log.Printf("Skipping the discussion #%d, its labels applied by the bot are unknown.", item.Number)
+			return exportRecord{}, false
+		}
+	}
+
+	record := exportRecord{
+		datasetRecord: datasetRecord{
+			Title: item.Title,
+			Body:  item.Body,
+		},
+		Kind:      kind,
+		Number:    item.Number,
+		URL:       item.URL,
+		CreatedAt: item.CreatedAt,
+	}
+
+	for _, l := range item.Labels {
+		if kind == kindDiscussions {
+			if slices.ContainsFunc(botLabels, func(name string) bool { return strings.EqualFold(name, l.Name) }) {
+				continue
+			}
+		} else if isBotActor(l.Actor, botLogin) {
+			continue
+		}
+		record.Labels = append(record.Labels, l.Name)
+		record.LabelEvents = append(record.LabelEvents, l)
+	}
+
+	return record, len(record.Labels) > 0
+}
+
+// markerLabels returns the labels listed in the marker of the latest bot comment. It reports false
+// if the marker may be in the older comments that were not fetched.
+func (i labeledItem) markerLabels(botLogin string) ([]string, bool) {
+	for j := len(i.Comments) - 1; j >= 0; j-- {
+		c := i.Comments[j]
+		if !isBotActor(c.Author, botLogin) {
+			continue
+		}
+		if marker, ok := parseCommentMarker(c.Body); ok {
+			return marker.Labels, true
+		}
+	}
+	return nil, !i.MoreComments
+}
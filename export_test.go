@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExportRecord(t *testing.T) {
+	appliedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	item := labeledItem{
+		Number: 1,
+		Title:  "Crash",
+		Labels: []appliedLabel{
+			{Name: "bug", Actor: "maintainer", AppliedAt: appliedAt},
+			{Name: "triage", Actor: "auto-labeler", AppliedAt: appliedAt},
+		},
+	}
+
+	t.Run("bot labels are excluded", func(t *testing.T) {
+		record, ok := newExportRecord(kindIssues, item, "auto-labeler")
+		require.True(t, ok)
+		assert.Equal(t, []string{"bug"}, record.Labels)
+		assert.Equal(t, []appliedLabel{{Name: "bug", Actor: "maintainer", AppliedAt: appliedAt}}, record.LabelEvents)
+	})
+
+	t.Run("only bot labels", func(t *testing.T) {
+		_, ok := newExportRecord(kindIssues, item, "maintainer")
+		assert.True(t, ok)
+
+		item := item
+		item.Labels = item.Labels[1:]
+		_, ok = newExportRecord(kindIssues, item, "auto-labeler")
+		assert.False(t, ok)
+	})
+
+	t.Run("other bots and unknown actors are excluded", func(t *testing.T) {
+		item := item
+		item.Labels = []appliedLabel{{Name: "dependencies", Actor: "dependabot[bot]"}, {Name: "bug"}}
+		_, ok := newExportRecord(kindIssues, item, "auto-labeler")
+		assert.False(t, ok)
+	})
+
+	t.Run("discussion labels applied by the bot are excluded", func(t *testing.T) {
+		item := item
+		item.Labels = []appliedLabel{{Name: "question"}, {Name: "area/cli"}}
+		item.Comments = []itemComment{
+			{Author: "maintainer", Body: `<!-- auto-label {"hash":"","labels":["bug"]} -->`},
+			{Author: "auto-labeler", Body: "Labels: area/cli\n" + commentMarker{Labels: []string{"area/cli"}}.String()},
+		}
+		record, ok := newExportRecord(kindDiscussions, item, "auto-labeler")
+		require.True(t, ok)
+		assert.Equal(t, []string{"question"}, record.Labels)
+	})
+
+	t.Run("discussion without the bot comment", func(t *testing.T) {
+		item := item
+		item.Labels = []appliedLabel{{Name: "question"}}
+		record, ok := newExportRecord(kindDiscussions, item, "auto-labeler")
+		require.True(t, ok)
+		assert.Equal(t, []string{"question"}, record.Labels)
+
+		item.MoreComments = true
+		_, ok = newExportRecord(kindDiscussions, item, "auto-labeler")
+		assert.False(t, ok, "the bot comment may be among the older comments")
+	})
+}
+
+func TestExportItems(t *testing.T) {
+	transport := &sequenceTransport{
+		responses: []string{
+			`{"data":{"repository":{"items":{"pageInfo":{"hasNextPage":true,"endCursor":"c1"},"nodes":[
+				{"number":1,"title":"Crash","body":"It crashes","labels":{"nodes":[{"name":"bug"}]},"timelineItems":{"nodes":[
+					{"__typename":"LabeledEvent","actor":{"login":"maintainer"},"label":{"name":"bug"},"createdAt":"2024-01-01T00:00:00Z"}
+				]}},
+				{"number":2,"title":"Unlabeled","labels":{"nodes":[]},"timelineItems":{"nodes":[]}}
+			]}}}}`,
+			`{"data":{"repository":{"items":{"pageInfo":{"hasNextPage":false},"nodes":[
+				{"number":3,"title":"Docs","labels":{"nodes":[{"name":"documentation"}]},"timelineItems":{"nodes":[
+					{"__typename":"LabeledEvent","actor":{"login":"maintainer"},"label":{"name":"documentation"},"createdAt":"2024-01-01T00:00:00Z"}
+				]}},
+				{"number":4,"title":"Unknown","labels":{"nodes":[{"name":"bug"}]},"timelineItems":{"nodes":[]}}
+			]}}}}`,
+		},
+	}
+	ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+	cfg := config{repoOwner: "owner", repoName: "repo"}
+
+	var buf bytes.Buffer
+	n, err := exportItems(context.TODO(), ghapi, cfg, kindIssues, 0, "auto-labeler", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, transport.requests, 2)
+	assert.Contains(t, transport.requests[1], `"after":"c1"`)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{
+		"title": "Crash", "body": "It crashes", "labels": ["bug"],
+		"kind": "issues", "number": 1, "url": "", "created_at": "0001-01-01T00:00:00Z",
+		"label_events": [{"name": "bug", "actor": "maintainer", "applied_at": "2024-01-01T00:00:00Z"}]
+	}`, lines[0])
+
+	records, err := readDataset(strings.NewReader(buf.String()))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"documentation"}, records[1].Labels)
+}
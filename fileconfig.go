@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigPath = ".github/auto-label.yml"
+
+// fileConfig holds the rules that are too complex for action inputs.
+// It is read from a YAML file in the repository.
+type fileConfig struct {
+	// SkipBots skips the items opened by bots, such as Dependabot or Renovate.
+	SkipBots bool `yaml:"skip_bots"`
+	// SkipAuthors are logins or patterns such as "*[bot]" of authors whose items are not labeled.
+	SkipAuthors []string `yaml:"skip_authors"`
+	// OnlyAssociations, if set, limits labeling to items whose authors have one of the associations,
+	// e.g. "FIRST_TIME_CONTRIBUTOR" or "NONE".
+	OnlyAssociations []string `yaml:"only_associations"`
+	// SkipAssociations are author associations whose items are not labeled, e.g. "MEMBER".
+	SkipAssociations []string `yaml:"skip_associations"`
+	// AuthorRules change the labeling of items by matching authors.
+	AuthorRules []authorRule `yaml:"author_rules"`
+	// FieldLabels map the answers of issue form dropdowns and checkboxes to labels,
+	// e.g. {"Component": {"CLI": "area/cli"}}.
+	FieldLabels map[string]map[string]string `yaml:"field_labels"`
+	// Priority is the rubric used when the priority estimation is enabled.
+	Priority priorityConfig `yaml:"priority"`
+	// SecurityComment is the text/template of the comment posted on security reports
+	// when no webhook is configured. It can use {{.PolicyURL}}, {{.Kind}} and {{.Title}}.
+	SecurityComment string `yaml:"security_comment"`
+	// ModerationAllowlist are logins or patterns of authors who are never moderated
+	// in addition to owners, members and collaborators.
+	ModerationAllowlist []string `yaml:"moderation_allowlist"`
+	// Routing assigns issues and requests reviews on pull requests by the applied labels.
+	Routing routingConfig `yaml:"routing"`
+	// Projects add the labeled issues and pull requests to Projects (v2).
+	Projects []projectRule `yaml:"projects"`
+	// Milestones set the milestone of the labeled issues and pull requests.
+	Milestones milestoneConfig `yaml:"milestones"`
+}
+
+// authorRule adds details to the prompt and excludes labels for the items of matching authors.
+type authorRule struct {
+	Authors        []string `yaml:"authors"`
+	Associations   []string `yaml:"associations"`
+	Details        string   `yaml:"details"`
+	ExcludedLabels []string `yaml:"excluded_labels"`
+}
+
+// loadFileConfig reads the config file. A missing file is not an error
+// unless the path was set explicitly.
+func loadFileConfig(path string) (fileConfig, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && path == defaultConfigPath {
+		return fileConfig{}, nil
+	} else if err != nil {
+		return fileConfig{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg fileConfig
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return fileConfig{}, fmt.Errorf("failed to decode config %q: %w", path, err)
+	}
+	return cfg, nil
+}
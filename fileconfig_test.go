@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFileConfig(t *testing.T) {
+	t.Run("happy", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "auto-label.yml")
+		content := `
+skip_bots: true
+skip_authors: ["renovate[bot]"]
+only_associations: [NONE, CONTRIBUTOR]
+author_rules:
+  - associations: [FIRST_TIME_CONTRIBUTOR]
+    details: Be welcoming.
+field_labels:
+  Component:
+    CLI: area/cli
+`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		cfg, err := loadFileConfig(path)
+		require.NoError(t, err)
+
+		expected := fileConfig{
+			SkipBots:         true,
+			SkipAuthors:      []string{"renovate[bot]"},
+			OnlyAssociations: []string{"NONE", "CONTRIBUTOR"},
+			AuthorRules: []authorRule{
+				{Associations: []string{"FIRST_TIME_CONTRIBUTOR"}, Details: "Be welcoming."},
+			},
+			FieldLabels: map[string]map[string]string{"Component": {"CLI": "area/cli"}},
+		}
+		assert.Equal(t, expected, cfg)
+	})
+
+	t.Run("missing explicit file", func(t *testing.T) {
+		_, err := loadFileConfig(filepath.Join(t.TempDir(), "missing.yml"))
+		require.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "auto-label.yml")
+		require.NoError(t, os.WriteFile(path, []byte("skip_bots: [\n"), 0o600))
+
+		_, err := loadFileConfig(path)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultIssueTemplates = ".github/ISSUE_TEMPLATE"
+
+	// noResponse is the value GitHub renders for optional fields left empty.
+	noResponse = "_No response_"
+)
+
+// issueForm is a YAML issue form, see
+// https://docs.github.com/en/communities/using-templates-to-encourage-useful-issues-and-pull-requests/syntax-for-issue-forms
+type issueForm struct {
+	Name string        `yaml:"name"`
+	Body []formElement `yaml:"body"`
+}
+
+type formElement struct {
+	Type       string `yaml:"type"`
+	ID         string `yaml:"id"`
+	Attributes struct {
+		Label   string       `yaml:"label"`
+		Options []formOption `yaml:"options"`
+	} `yaml:"attributes"`
+}
+
+// formOption is an option of a dropdown or checkboxes.
+type formOption string
+
+func (o *formOption) UnmarshalYAML(node *yaml.Node) error {
+	// dropdowns list the options as strings and checkboxes as mappings with a label
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode((*string)(o))
+	}
+
+	var option struct {
+		Label string `yaml:"label"`
+	}
+	if err := node.Decode(&option); err != nil {
+		return err
+	}
+	*o = formOption(option.Label)
+	return nil
+}
+
+// formField is an answer to a field of the issue form.
+type formField struct {
+	Name  string
+	Type  string
+	Value string
+	// Answers are the chosen options of dropdowns and checkboxes.
+	Answers []string
+}
+
+// loadIssueForms reads the issue forms from the directory. A missing directory is not an error,
+// malformed forms are skipped.
+func loadIssueForms(dir string) ([]issueForm, error) {
+	var paths []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list issue forms: %w", err)
+		}
+		paths = append(paths, matches...)
+	}
+
+	var forms []issueForm
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read issue form: %w", err)
+		}
+
+		var form issueForm
+		if err := yaml.Unmarshal(b, &form); err != nil {
+			log.Printf("Skipping the issue form %q: %v", path, err)
+			continue
+		}
+
+		// config.yml of the template chooser has no body
+		if len(form.Body) > 0 {
+			forms = append(forms, form)
+		}
+	}
+	return forms, nil
+}
+
+var headingRegexp = regexp.MustCompile(`(?m)^### (.+)$`)
+
+// parseIssueForm splits the body rendered from the issue form that matches it best into fields.
+// It returns nil if the body was not created from any of the forms, that is if no form has
+// more than half of its fields among the headings of the body.
+func parseIssueForm(body string, forms []issueForm) []formField {
+	sections := make(map[string]string)
+	matches := headingRegexp.FindAllStringSubmatchIndex(body, -1)
+	for i, m := range matches {
+		end := len(body)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		heading := strings.TrimSpace(body[m[2]:m[3]])
+		sections[heading] = strings.TrimSpace(body[m[1]:end])
+	}
+
+	var (
+		best      []formField
+		bestCount int
+	)
+	for _, form := range forms {
+		var (
+			fields []formField
+			total  int
+		)
+		for _, e := range form.Body {
+			if e.Type == "markdown" {
+				continue
+			}
+			total++
+
+			value, ok := sections[e.Attributes.Label]
+			if !ok {
+				continue
+			}
+			answers := fieldAnswers(e, value)
+			fields = append(fields, formField{
+				Name:    e.Attributes.Label,
+				Type:    e.Type,
+				Value:   fieldValue(e.Type, value, answers),
+				Answers: answers,
+			})
+		}
+		if len(fields)*2 > total && len(fields) > bestCount {
+			best, bestCount = fields, len(fields)
+		}
+	}
+	return best
+}
+
+var checkedRegexp = regexp.MustCompile(`(?m)^- \[[xX]\] (.+)$`)
+
+func fieldValue(typ, value string, answers []string) string {
+	if value == noResponse {
+		return ""
+	}
+
+	if typ == "checkboxes" {
+		return strings.Join(answers, ", ")
+	}
+	return value
+}
+
+// fieldAnswers returns the chosen options of dropdowns and checkboxes.
+func fieldAnswers(e formElement, value string) []string {
+	switch {
+	case value == noResponse:
+		return nil
+	case e.Type == "checkboxes":
+		var checked []string
+		for _, m := range checkedRegexp.FindAllStringSubmatch(value, -1) {
+			checked = append(checked, strings.TrimSpace(m[1]))
+		}
+		return checked
+	case e.Type == "dropdown":
+		return splitAnswers(value, e.Attributes.Options)
+	}
+	return nil
+}
+
+// splitAnswers splits the answers of a multiple choice dropdown, which are joined with commas,
+// by its options, so that the options containing commas are kept whole. The value is a single
+// answer if it does not consist of the options.
+func splitAnswers(value string, options []formOption) []string {
+	var answers []string
+	for rest := value; rest != ""; {
+		var longest string
+		for _, o := range options {
+			option := string(o)
+			if len(option) > len(longest) && (rest == option || strings.HasPrefix(rest, option+", ")) {
+				longest = option
+			}
+		}
+		if longest == "" {
+			return []string{value}
+		}
+		answers = append(answers, longest)
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, longest), ", ")
+	}
+	return answers
+}
+
+// formatFields renders the fields for the prompt.
+func formatFields(fields []formField) string {
+	var parts []string
+	for _, f := range fields {
+		if f.Value == "" {
+			continue
+		}
+		if strings.Contains(f.Value, "\n") {
+			parts = append(parts, fmt.Sprintf("%s:\n%s", f.Name, f.Value))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s: %s", f.Name, f.Value))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// fieldLabel is a label mapped from an answer of the issue form.
+type fieldLabel struct {
+	Label string
+	Field string
+	Value string
+}
+
+// fieldLabels maps the answers of dropdowns and checkboxes to labels.
+// The mapping is keyed by the field name and then by the answer, both case-insensitive.
+func fieldLabels(fields []formField, mapping map[string]map[string]string) []fieldLabel {
+	var labels []fieldLabel
+	for _, f := range fields {
+		if f.Type != "dropdown" && f.Type != "checkboxes" {
+			continue
+		}
+
+		values := lookupFold(mapping, f.Name)
+		for _, answer := range f.Answers {
+			label := lookupFold(values, answer)
+			if label != "" && !slices.ContainsFunc(labels, func(l fieldLabel) bool { return l.Label == label }) {
+				labels = append(labels, fieldLabel{Label: label, Field: f.Name, Value: answer})
+			}
+		}
+	}
+	return labels
+}
+
+func lookupFold[T any](m map[string]T, key string) T {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	var zero T
+	return zero
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bugForm = `name: Bug report
+description: Report a bug
+labels: ["kind/bug"]
+body:
+  - type: markdown
+    attributes:
+      value: Thanks for reporting!
+  - type: dropdown
+    id: component
+    attributes:
+      label: Component
+      options: [CLI, Server]
+  - type: textarea
+    id: what-happened
+    attributes:
+      label: What happened?
+  - type: input
+    id: version
+    attributes:
+      label: Version
+  - type: checkboxes
+    id: platforms
+    attributes:
+      label: Platforms
+      options:
+        - label: Linux
+        - label: macOS
+`
+
+const bugBody = `### Component
+
+CLI
+
+### What happened?
+
+It crashed:
+` + "```" + `
+panic: oops
+` + "```" + `
+
+### Version
+
+_No response_
+
+### Platforms
+
+- [X] Linux
+- [ ] macOS
+`
+
+func TestLoadIssueForms(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bug.yml"), []byte(bugForm), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte("blank_issues_enabled: false\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yml"), []byte("name: [Broken\n"), 0o644))
+
+	forms, err := loadIssueForms(dir)
+	require.NoError(t, err)
+	require.Len(t, forms, 1)
+	assert.Equal(t, "Bug report", forms[0].Name)
+	assert.Len(t, forms[0].Body, 5)
+
+	forms, err = loadIssueForms(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, forms)
+}
+
+func TestParseIssueForm(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bug.yaml"), []byte(bugForm), 0o644))
+	forms, err := loadIssueForms(dir)
+	require.NoError(t, err)
+
+	t.Run("form body", func(t *testing.T) {
+		fields := parseIssueForm(bugBody, forms)
+		assert.Equal(t, []formField{
+			{Name: "Component", Type: "dropdown", Value: "CLI", Answers: []string{"CLI"}},
+			{Name: "What happened?", Type: "textarea", Value: "It crashed:\n```\npanic: oops\n```"},
+			{Name: "Version", Type: "input", Value: ""},
+			{Name: "Platforms", Type: "checkboxes", Value: "Linux", Answers: []string{"Linux"}},
+		}, fields)
+
+		assert.Equal(t,
+			"Component: CLI\n\nWhat happened?:\nIt crashed:\n```\npanic: oops\n```\n\nPlatforms: Linux",
+			formatFields(fields),
+		)
+	})
+
+	t.Run("free-form body", func(t *testing.T) {
+		assert.Nil(t, parseIssueForm("It crashed.\n\n### Notes\n\nNothing", forms))
+	})
+
+	t.Run("few matching headings", func(t *testing.T) {
+		assert.Nil(t, parseIssueForm("It crashed.\n\n### Version\n\n1.0", forms))
+	})
+}
+
+func TestSplitAnswers(t *testing.T) {
+	options := []formOption{"CLI", "Server", "Server, API"}
+
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{value: "CLI", expected: []string{"CLI"}},
+		{value: "CLI, Server", expected: []string{"CLI", "Server"}},
+		{value: "Server, API, CLI", expected: []string{"Server, API", "CLI"}},
+		{value: "CLI, Other", expected: []string{"CLI, Other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.expected, splitAnswers(tt.value, options))
+		})
+	}
+}
+
+func TestFieldLabels(t *testing.T) {
+	fields := []formField{
+		{Name: "Component", Type: "dropdown", Value: "CLI, Server, API", Answers: []string{"CLI", "Server, API"}},
+		{Name: "What happened?", Type: "textarea", Value: "CLI"},
+		{Name: "Platforms", Type: "checkboxes", Value: "Linux", Answers: []string{"Linux"}},
+	}
+	mapping := map[string]map[string]string{
+		"component":      {"cli": "area/cli", "Server, API": "area/server"},
+		"What happened?": {"CLI": "area/cli"},
+		"Platforms":      {"Linux": "os/linux"},
+	}
+
+	assert.Equal(t, []fieldLabel{
+		{Label: "area/cli", Field: "Component", Value: "CLI"},
+		{Label: "area/server", Field: "Component", Value: "Server, API"},
+		{Label: "os/linux", Field: "Platforms", Value: "Linux"},
+	}, fieldLabels(fields, mapping))
+}
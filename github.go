@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 )
 
 type GitHubGraphQLClient struct {
@@ -40,9 +43,15 @@ func (c *GitHubGraphQLClient) ReplaceLabels(ctx context.Context, labelableID str
 	return nil
 }
 
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
 type gqlRepo struct {
 	Labels struct {
-		Nodes []Label `json:"nodes"`
+		PageInfo pageInfo `json:"pageInfo"`
+		Nodes    []Label  `json:"nodes"`
 	} `json:"labels"`
 }
 
@@ -50,36 +59,1044 @@ type Label struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	ID          string `json:"id"`
+	Color       string `json:"color,omitempty"`
 }
 
 func (r gqlRepo) labels() []Label {
 	return r.Labels.Nodes
 }
 
-func buildGetLabelsRequest(owner, name string) string {
-	tpl := `{"query":"query{repository(owner:\"%s\",name:\"%s\"){labels(first:100){nodes{name description id}}}}"}`
-	return fmt.Sprintf(tpl, owner, name)
+func buildGetLabelsRequest(owner, name, after string) string {
+	cursor := "null"
+	if after != "" {
+		cursor = fmt.Sprintf(`\"%s\"`, after)
+	}
+	tpl := `{"query":"query{repository(owner:\"%s\",name:\"%s\"){labels(first:100, after:%s){pageInfo{hasNextPage endCursor} nodes{name description id color}}}}"}`
+	return fmt.Sprintf(tpl, owner, name, cursor)
 }
 
 func (c *GitHubGraphQLClient) FetchRepoLabels(ctx context.Context, owner, repo string) ([]Label, error) {
-	data, err := c.request(ctx, buildGetLabelsRequest(owner, repo))
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch labels: %w", err)
+	var (
+		labels []Label
+		after  string
+	)
+
+	for {
+		data, err := c.request(ctx, buildGetLabelsRequest(owner, repo, after))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch labels: %w", err)
+		}
+
+		var r struct {
+			gqlRepo `json:"repository"`
+		}
+
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		labels = append(labels, r.gqlRepo.labels()...)
+
+		if !r.Labels.PageInfo.HasNextPage {
+			return labels, nil
+		}
+		after = r.Labels.PageInfo.EndCursor
 	}
+}
+
+const repositoryIDQuery = `query($owner:String!, $name:String!){repository(owner:$owner, name:$name){id}}`
 
+// FetchRepositoryID returns the node ID of the repository.
+func (c *GitHubGraphQLClient) FetchRepositoryID(ctx context.Context, owner, repo string) (string, error) {
 	var r struct {
-		gqlRepo `json:"repository"`
+		Repository struct {
+			ID string `json:"id"`
+		} `json:"repository"`
 	}
 
-	if err := json.Unmarshal(data, &r); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.query(ctx, repositoryIDQuery, map[string]any{"owner": owner, "name": repo}, &r); err != nil {
+		return "", fmt.Errorf("failed to fetch repository: %w", err)
+	}
+	return r.Repository.ID, nil
+}
+
+const createLabelMutation = `mutation($repositoryId:ID!, $name:String!, $color:String!, $description:String){
+	createLabel(input:{repositoryId:$repositoryId, name:$name, color:$color, description:$description}){clientMutationId}
+}`
+
+// CreateLabel creates the label in the repository. The color is a hex code without the leading #.
+func (c *GitHubGraphQLClient) CreateLabel(ctx context.Context, repoID, name, color, description string) error {
+	vars := map[string]any{"repositoryId": repoID, "name": name, "color": color, "description": description}
+	if err := c.query(ctx, createLabelMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to create label %q: %w", name, err)
+	}
+	return nil
+}
+
+const updateLabelMutation = `mutation($id:ID!, $name:String!, $color:String!, $description:String){
+	updateLabel(input:{id:$id, name:$name, color:$color, description:$description}){clientMutationId}
+}`
+
+// UpdateLabel changes the label in place, so renaming it keeps it on the items it is applied to.
+func (c *GitHubGraphQLClient) UpdateLabel(ctx context.Context, id, name, color, description string) error {
+	vars := map[string]any{"id": id, "name": name, "color": color, "description": description}
+	if err := c.query(ctx, updateLabelMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to update label %q: %w", name, err)
+	}
+	return nil
+}
+
+const deleteLabelMutation = `mutation($id:ID!){deleteLabel(input:{id:$id}){clientMutationId}}`
+
+// DeleteLabel deletes the label, removing it from all items.
+func (c *GitHubGraphQLClient) DeleteLabel(ctx context.Context, id string) error {
+	if err := c.query(ctx, deleteLabelMutation, map[string]any{"id": id}, nil); err != nil {
+		return fmt.Errorf("failed to delete label: %w", err)
+	}
+	return nil
+}
+
+func buildLabelItemsQuery(kind itemKind) string {
+	return fmt.Sprintf(`query($owner:String!, $name:String!, $label:String!, $after:String){
+	repository(owner:$owner, name:$name){
+		label(name:$label){
+			items: %s(first:100, after:$after){
+				pageInfo{hasNextPage endCursor}
+				nodes{id}
+			}
+		}
+	}
+}`, kind)
+}
+
+// FetchLabelItems returns the IDs of the issues and the pull requests with the label.
+func (c *GitHubGraphQLClient) FetchLabelItems(ctx context.Context, owner, repo, label string) ([]string, error) {
+	var ids []string
+	for _, kind := range []itemKind{kindIssues, kindPullRequests} {
+		var after *string
+		for {
+			var r struct {
+				Repository struct {
+					Label *struct {
+						Items struct {
+							PageInfo pageInfo `json:"pageInfo"`
+							Nodes    []struct {
+								ID string `json:"id"`
+							} `json:"nodes"`
+						} `json:"items"`
+					} `json:"label"`
+				} `json:"repository"`
+			}
+
+			vars := map[string]any{"owner": owner, "name": repo, "label": label, "after": after}
+			if err := c.query(ctx, buildLabelItemsQuery(kind), vars, &r); err != nil {
+				return nil, fmt.Errorf("failed to fetch %s of label %q: %w", kind, label, err)
+			}
+			if r.Repository.Label == nil {
+				return nil, fmt.Errorf("label %q not found", label)
+			}
+
+			for _, n := range r.Repository.Label.Items.Nodes {
+				ids = append(ids, n.ID)
+			}
+
+			page := r.Repository.Label.Items.PageInfo
+			if !page.HasNextPage {
+				break
+			}
+			after = &page.EndCursor
+		}
+	}
+	return ids, nil
+}
+
+const viewerQuery = `query{viewer{login}}`
+
+// FetchViewerLogin returns the login of the token owner.
+func (c *GitHubGraphQLClient) FetchViewerLogin(ctx context.Context) (string, error) {
+	var r struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+
+	if err := c.query(ctx, viewerQuery, nil, &r); err != nil {
+		return "", fmt.Errorf("failed to fetch viewer: %w", err)
+	}
+	return r.Viewer.Login, nil
+}
+
+type actor struct {
+	Login string `json:"login"`
+}
+
+type gqlLabelEvent struct {
+	Typename  string    `json:"__typename"`
+	Actor     *actor    `json:"actor"`
+	Label     Label     `json:"label"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// labeledNode is an issue, a pull request or a discussion with its labels and labeling history.
+type labeledNode struct {
+	ID        string    `json:"id"`
+	Number    int       `json:"number"`
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+	Labels    struct {
+		Nodes []Label `json:"nodes"`
+	} `json:"labels"`
+	TimelineItems struct {
+		Nodes []gqlLabelEvent `json:"nodes"`
+	} `json:"timelineItems"`
+	Comments *struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Author *struct {
+				Login string `json:"login"`
+			} `json:"author"`
+			Body string `json:"body"`
+		} `json:"nodes"`
+	} `json:"comments"`
+}
+
+// itemComment is a comment of a labeled discussion.
+type itemComment struct {
+	Author string
+	Body   string
+}
+
+// appliedLabel is a label of an item and the actor who applied it.
+type appliedLabel struct {
+	Name      string    `json:"name"`
+	Actor     string    `json:"actor"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+type labeledItem struct {
+	Number    int
+	URL       string
+	Title     string
+	Body      string
+	CreatedAt time.Time
+	Labels    []appliedLabel
+	// Comments are the latest comments of a discussion, the marker of the bot among them
+	// tells which labels the bot applied.
+	Comments []itemComment
+	// MoreComments is set if the discussion has older comments than Comments.
+	MoreComments bool
+}
+
+func (n labeledNode) item() labeledItem {
+	item := labeledItem{
+		Number:    n.Number,
+		URL:       n.URL,
+		Title:     n.Title,
+		Body:      n.Body,
+		CreatedAt: n.CreatedAt,
+	}
+
+	for _, l := range n.Labels.Nodes {
+		applied := appliedLabel{Name: l.Name}
+		// the last labeling event determines who applied the current label
+		for _, e := range n.TimelineItems.Nodes {
+			if e.Typename == "LabeledEvent" && e.Label.Name == l.Name {
+				applied.AppliedAt = e.CreatedAt
+				applied.Actor = ""
+				if e.Actor != nil {
+					applied.Actor = e.Actor.Login
+				}
+			}
+		}
+		item.Labels = append(item.Labels, applied)
+	}
+
+	if n.Comments != nil {
+		for _, c := range n.Comments.Nodes {
+			comment := itemComment{Body: c.Body}
+			if c.Author != nil {
+				comment.Author = c.Author.Login
+			}
+			item.Comments = append(item.Comments, comment)
+		}
+		item.MoreComments = n.Comments.TotalCount > len(n.Comments.Nodes)
+	}
+	return item
+}
+
+const labelEventsFragment = `timelineItems(itemTypes:[LABELED_EVENT,UNLABELED_EVENT], last:100){nodes{
+	__typename
+	... on LabeledEvent{actor{login} label{name} createdAt}
+	... on UnlabeledEvent{actor{login} label{name} createdAt}
+}}`
+
+const labelEventsQuery = `query($id:ID!){
+	node(id:$id){
+		... on Issue{` + labelEventsFragment + `}
+		... on PullRequest{` + labelEventsFragment + `}
+	}
+}`
+
+// FetchLabelEvents returns the labeling history of an issue or a pull request.
+func (c *GitHubGraphQLClient) FetchLabelEvents(ctx context.Context, nodeID string) ([]gqlLabelEvent, error) {
+	var r struct {
+		Node labeledNode `json:"node"`
+	}
+
+	if err := c.query(ctx, labelEventsQuery, map[string]any{"id": nodeID}, &r); err != nil {
+		return nil, fmt.Errorf("failed to fetch label events: %w", err)
+	}
+	return r.Node.TimelineItems.Nodes, nil
+}
+
+const closedIssuesQuery = `query($owner:String!, $name:String!, $first:Int!, $after:String){
+	repository(owner:$owner, name:$name){
+		issues(first:$first, after:$after, states:CLOSED, orderBy:{field:UPDATED_AT, direction:DESC}){
+			pageInfo{hasNextPage endCursor}
+			nodes{id number url title body createdAt labels(first:50){nodes{name}} ` + labelEventsFragment + `}
+		}
+	}
+}`
+
+// closedIssuesPageSize is the maximum page size allowed by the GraphQL API.
+const closedIssuesPageSize = 100
+
+// FetchClosedIssues returns up to count most recently updated closed issues with their labeling history.
+func (c *GitHubGraphQLClient) FetchClosedIssues(ctx context.Context, owner, repo string, count int) ([]labeledItem, error) {
+	var (
+		items []labeledItem
+		after *string
+	)
+
+	for len(items) < count {
+		var r struct {
+			Repository struct {
+				Issues struct {
+					PageInfo pageInfo      `json:"pageInfo"`
+					Nodes    []labeledNode `json:"nodes"`
+				} `json:"issues"`
+			} `json:"repository"`
+		}
+
+		vars := map[string]any{"owner": owner, "name": repo, "first": min(count-len(items), closedIssuesPageSize), "after": after}
+		if err := c.query(ctx, closedIssuesQuery, vars, &r); err != nil {
+			return nil, fmt.Errorf("failed to fetch closed issues: %w", err)
+		}
+
+		for _, n := range r.Repository.Issues.Nodes {
+			items = append(items, n.item())
+		}
+
+		page := r.Repository.Issues.PageInfo
+		if !page.HasNextPage {
+			break
+		}
+		after = &page.EndCursor
+	}
+	return items, nil
+}
+
+// itemKind is the name of a repository connection with labelable items.
+type itemKind string
+
+const (
+	kindIssues       itemKind = "issues"
+	kindPullRequests itemKind = "pullRequests"
+	kindDiscussions  itemKind = "discussions"
+)
+
+func buildLabeledItemsQuery(kind itemKind) string {
+	fields := "id number url title body createdAt labels(first:50){nodes{name}}"
+	// discussions have no timeline, the labels applied by the bot are known from its comment
+	if kind != kindDiscussions {
+		fields += " " + labelEventsFragment
+	} else {
+		fields += " comments(last:50){totalCount nodes{author{login} body}}"
+	}
+
+	return fmt.Sprintf(`query($owner:String!, $name:String!, $first:Int!, $after:String){
+	repository(owner:$owner, name:$name){
+		items: %s(first:$first, after:$after, orderBy:{field:CREATED_AT, direction:DESC}){
+			pageInfo{hasNextPage endCursor}
+			nodes{%s}
+		}
+	}
+}`, kind, fields)
+}
+
+// FetchLabeledItems returns a page of issues, pull requests or discussions with their labeling history.
+func (c *GitHubGraphQLClient) FetchLabeledItems(
+	ctx context.Context, owner, repo string, kind itemKind, first int, after string,
+) ([]labeledItem, pageInfo, error) {
+	var r struct {
+		Repository struct {
+			Items struct {
+				PageInfo pageInfo      `json:"pageInfo"`
+				Nodes    []labeledNode `json:"nodes"`
+			} `json:"items"`
+		} `json:"repository"`
+	}
+
+	vars := map[string]any{"owner": owner, "name": repo, "first": first}
+	if after != "" {
+		vars["after"] = after
+	}
+
+	if err := c.query(ctx, buildLabeledItemsQuery(kind), vars, &r); err != nil {
+		return nil, pageInfo{}, fmt.Errorf("failed to fetch %s: %w", kind, err)
+	}
+
+	var items []labeledItem
+	for _, n := range r.Repository.Items.Nodes {
+		items = append(items, n.item())
+	}
+	return items, r.Repository.Items.PageInfo, nil
+}
+
+const permissionQuery = `query($owner:String!, $name:String!, $login:String!){
+	repository(owner:$owner, name:$name){
+		collaborators(query:$login, first:10){edges{permission node{login}}}
+	}
+}`
+
+// FetchPermission returns the permission of the user in the repository, e.g. "WRITE" or "TRIAGE".
+// It returns an empty string if the user is not a collaborator.
+func (c *GitHubGraphQLClient) FetchPermission(ctx context.Context, owner, repo, login string) (string, error) {
+	var r struct {
+		Repository struct {
+			Collaborators struct {
+				Edges []struct {
+					Permission string `json:"permission"`
+					Node       actor  `json:"node"`
+				} `json:"edges"`
+			} `json:"collaborators"`
+		} `json:"repository"`
+	}
+
+	vars := map[string]any{"owner": owner, "name": repo, "login": login}
+	if err := c.query(ctx, permissionQuery, vars, &r); err != nil {
+		return "", fmt.Errorf("failed to fetch permission: %w", err)
+	}
+
+	// the query matches logins by prefix
+	for _, e := range r.Repository.Collaborators.Edges {
+		if strings.EqualFold(e.Node.Login, login) {
+			return e.Permission, nil
+		}
+	}
+	return "", nil
+}
+
+const addReactionMutation = `mutation($subjectId:ID!, $content:ReactionContent!){
+	addReaction(input:{subjectId:$subjectId, content:$content}){clientMutationId}
+}`
+
+// AddReaction reacts to a comment, an issue or a discussion, e.g. with "EYES" or "THUMBS_UP".
+func (c *GitHubGraphQLClient) AddReaction(ctx context.Context, subjectID string, content string) error {
+	vars := map[string]any{"subjectId": subjectID, "content": content}
+	if err := c.query(ctx, addReactionMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to add reaction: %w", err)
+	}
+	return nil
+}
+
+// botComment is a comment left by the action.
+type botComment struct {
+	ID     string
+	Body   string
+	Marker commentMarker
+	// Approvers are the users who reacted with 👍.
+	Approvers []string
+}
+
+const commentsFragment = `comments(last:100){nodes{
+	id body viewerDidAuthor
+	reactions(content:THUMBS_UP, first:50){nodes{user{login}}}
+}}`
+
+const botCommentQuery = `query($id:ID!){
+	node(id:$id){
+		... on Issue{` + commentsFragment + `}
+		... on PullRequest{` + commentsFragment + `}
+		... on Discussion{` + commentsFragment + `}
+	}
+}`
+
+// FetchBotComment returns the latest comment of the token owner with the marker,
+// or nil if there is none.
+func (c *GitHubGraphQLClient) FetchBotComment(ctx context.Context, subjectID string) (*botComment, error) {
+	var r struct {
+		Node struct {
+			Comments struct {
+				Nodes []struct {
+					ID              string `json:"id"`
+					Body            string `json:"body"`
+					ViewerDidAuthor bool   `json:"viewerDidAuthor"`
+					Reactions       struct {
+						Nodes []struct {
+							User actor `json:"user"`
+						} `json:"nodes"`
+					} `json:"reactions"`
+				} `json:"nodes"`
+			} `json:"comments"`
+		} `json:"node"`
+	}
+
+	if err := c.query(ctx, botCommentQuery, map[string]any{"id": subjectID}, &r); err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+
+	nodes := r.Node.Comments.Nodes
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if !nodes[i].ViewerDidAuthor {
+			continue
+		}
+		if marker, ok := parseCommentMarker(nodes[i].Body); ok {
+			comment := &botComment{ID: nodes[i].ID, Body: nodes[i].Body, Marker: marker}
+			for _, r := range nodes[i].Reactions.Nodes {
+				comment.Approvers = append(comment.Approvers, r.User.Login)
+			}
+			return comment, nil
+		}
+	}
+	return nil, nil
+}
+
+const updateIssueCommentMutation = `mutation($id:ID!, $body:String!){
+	updateIssueComment(input:{id:$id, body:$body}){clientMutationId}
+}`
+
+func (c *GitHubGraphQLClient) UpdateComment(ctx context.Context, commentID string, body string) error {
+	vars := map[string]any{"id": commentID, "body": body}
+	if err := c.query(ctx, updateIssueCommentMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+	return nil
+}
+
+const updateDiscussionCommentMutation = `mutation($id:ID!, $body:String!){
+	updateDiscussionComment(input:{commentId:$id, body:$body}){clientMutationId}
+}`
+
+func (c *GitHubGraphQLClient) UpdateDiscussionComment(ctx context.Context, commentID string, body string) error {
+	vars := map[string]any{"id": commentID, "body": body}
+	if err := c.query(ctx, updateDiscussionCommentMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to update discussion comment: %w", err)
+	}
+	return nil
+}
+
+const removeLabelsMutation = `mutation($id:ID!, $labelIds:[ID!]!){
+	removeLabelsFromLabelable(input:{labelableId:$id, labelIds:$labelIds}){clientMutationId}
+}`
+
+func (c *GitHubGraphQLClient) RemoveLabels(ctx context.Context, labelableID string, labelIDs []string) error {
+	vars := map[string]any{"id": labelableID, "labelIds": labelIDs}
+	if err := c.query(ctx, removeLabelsMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to remove labels: %w", err)
+	}
+	return nil
+}
+
+// issueSummary is an issue found by search.
+type issueSummary struct {
+	ID     string `json:"id"`
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	State  string `json:"state"`
+}
+
+const searchIssuesQuery = `query($query:String!, $first:Int!){
+	search(query:$query, type:ISSUE, first:$first){
+		nodes{... on Issue{id number url title body state}}
+	}
+}`
+
+// SearchIssues returns the issues matching the search query in the order of relevance.
+func (c *GitHubGraphQLClient) SearchIssues(ctx context.Context, query string, first int) ([]issueSummary, error) {
+	var r struct {
+		Search struct {
+			Nodes []issueSummary `json:"nodes"`
+		} `json:"search"`
+	}
+
+	vars := map[string]any{"query": query, "first": first}
+	if err := c.query(ctx, searchIssuesQuery, vars, &r); err != nil {
+		return nil, fmt.Errorf("failed to search issues: %w", err)
+	}
+
+	// pull requests matched by the query are returned as empty nodes
+	return slices.DeleteFunc(r.Search.Nodes, func(i issueSummary) bool {
+		return i.ID == ""
+	}), nil
+}
+
+// repoFile is a file on the default branch of a repository.
+type repoFile struct {
+	Branch  string
+	HeadOID string
+	// Text is empty if the file does not exist.
+	Text string
+}
+
+const fileQuery = `query($owner:String!, $name:String!, $expression:String!){
+	repository(owner:$owner, name:$name){
+		defaultBranchRef{name target{oid}}
+		object(expression:$expression){... on Blob{text}}
+	}
+}`
+
+// FetchFile returns the file from the default branch along with the branch head,
+// which is needed to commit changes to the file.
+func (c *GitHubGraphQLClient) FetchFile(ctx context.Context, owner, repo, path string) (repoFile, error) {
+	var r struct {
+		Repository struct {
+			DefaultBranchRef struct {
+				Name   string `json:"name"`
+				Target struct {
+					OID string `json:"oid"`
+				} `json:"target"`
+			} `json:"defaultBranchRef"`
+			Object *struct {
+				Text string `json:"text"`
+			} `json:"object"`
+		} `json:"repository"`
+	}
+
+	vars := map[string]any{"owner": owner, "name": repo, "expression": "HEAD:" + path}
+	if err := c.query(ctx, fileQuery, vars, &r); err != nil {
+		return repoFile{}, fmt.Errorf("failed to fetch file %q: %w", path, err)
 	}
 
-	return r.gqlRepo.labels(), nil
+	file := repoFile{
+		Branch:  r.Repository.DefaultBranchRef.Name,
+		HeadOID: r.Repository.DefaultBranchRef.Target.OID,
+	}
+	if r.Repository.Object != nil {
+		file.Text = r.Repository.Object.Text
+	}
+	return file, nil
+}
+
+const createCommitMutation = `mutation($input:CreateCommitOnBranchInput!){
+	createCommitOnBranch(input:$input){commit{oid}}
+}`
+
+// CommitFile writes the file to the branch the base was fetched from.
+// It fails if the branch has moved since then.
+func (c *GitHubGraphQLClient) CommitFile(
+	ctx context.Context, owner, repo string, base repoFile, path string, content []byte, headline string,
+) error {
+	input := map[string]any{
+		"branch": map[string]any{
+			"repositoryNameWithOwner": owner + "/" + repo,
+			"branchName":              base.Branch,
+		},
+		"message":         map[string]any{"headline": headline},
+		"expectedHeadOid": base.HeadOID,
+		"fileChanges": map[string]any{
+			"additions": []map[string]any{
+				{"path": path, "contents": base64.StdEncoding.EncodeToString(content)},
+			},
+		},
+	}
+
+	if err := c.query(ctx, createCommitMutation, map[string]any{"input": input}, nil); err != nil {
+		return fmt.Errorf("failed to commit file %q: %w", path, err)
+	}
+	return nil
+}
+
+const closeIssueMutation = `mutation($id:ID!){
+	closeIssue(input:{issueId:$id, stateReason:NOT_PLANNED}){clientMutationId}
+}`
+
+// CloseIssue closes the issue as not planned.
+func (c *GitHubGraphQLClient) CloseIssue(ctx context.Context, issueID string) error {
+	if err := c.query(ctx, closeIssueMutation, map[string]any{"id": issueID}, nil); err != nil {
+		return fmt.Errorf("failed to close issue: %w", err)
+	}
+	return nil
+}
+
+const closePullRequestMutation = `mutation($id:ID!){
+	closePullRequest(input:{pullRequestId:$id}){clientMutationId}
+}`
+
+// ClosePullRequest closes the pull request without merging it.
+func (c *GitHubGraphQLClient) ClosePullRequest(ctx context.Context, pullRequestID string) error {
+	if err := c.query(ctx, closePullRequestMutation, map[string]any{"id": pullRequestID}, nil); err != nil {
+		return fmt.Errorf("failed to close pull request: %w", err)
+	}
+	return nil
+}
+
+const closeDiscussionMutation = `mutation($id:ID!){
+	closeDiscussion(input:{discussionId:$id, reason:OUTDATED}){clientMutationId}
+}`
+
+// CloseDiscussion closes the discussion as outdated.
+func (c *GitHubGraphQLClient) CloseDiscussion(ctx context.Context, discussionID string) error {
+	if err := c.query(ctx, closeDiscussionMutation, map[string]any{"id": discussionID}, nil); err != nil {
+		return fmt.Errorf("failed to close discussion: %w", err)
+	}
+	return nil
+}
+
+const lockMutation = `mutation($id:ID!){
+	lockLockable(input:{lockableId:$id, lockReason:SPAM}){clientMutationId}
+}`
+
+// LockLockable locks the conversation of the issue or the discussion as spam.
+func (c *GitHubGraphQLClient) LockLockable(ctx context.Context, lockableID string) error {
+	if err := c.query(ctx, lockMutation, map[string]any{"id": lockableID}, nil); err != nil {
+		return fmt.Errorf("failed to lock: %w", err)
+	}
+	return nil
+}
+
+const minimizeCommentMutation = `mutation($id:ID!){
+	minimizeComment(input:{subjectId:$id, classifier:SPAM}){clientMutationId}
+}`
+
+// MinimizeComment hides the comment as spam.
+func (c *GitHubGraphQLClient) MinimizeComment(ctx context.Context, commentID string) error {
+	if err := c.query(ctx, minimizeCommentMutation, map[string]any{"id": commentID}, nil); err != nil {
+		return fmt.Errorf("failed to minimize comment: %w", err)
+	}
+	return nil
+}
+
+const teamMembersQuery = `query($org:String!, $slug:String!){
+	organization(login:$org){
+		team(slug:$slug){members(first:100){nodes{login}}}
+	}
+}`
+
+// FetchTeamMembers returns the logins of the team members. Reading teams requires the read:org scope.
+func (c *GitHubGraphQLClient) FetchTeamMembers(ctx context.Context, org, slug string) ([]string, error) {
+	var r struct {
+		Organization struct {
+			Team *struct {
+				Members struct {
+					Nodes []actor `json:"nodes"`
+				} `json:"members"`
+			} `json:"team"`
+		} `json:"organization"`
+	}
+
+	if err := c.query(ctx, teamMembersQuery, map[string]any{"org": org, "slug": slug}, &r); err != nil {
+		return nil, fmt.Errorf("failed to fetch team %s/%s: %w", org, slug, err)
+	}
+
+	if r.Organization.Team == nil {
+		return nil, fmt.Errorf("team %s/%s not found", org, slug)
+	}
+
+	var logins []string
+	for _, m := range r.Organization.Team.Members.Nodes {
+		logins = append(logins, m.Login)
+	}
+	return logins, nil
+}
+
+const issueCountQuery = `query($query:String!){
+	search(query:$query, type:ISSUE, first:0){issueCount}
+}`
+
+// CountOpenAssigned returns the number of open issues assigned to the user
+// or, for pull requests, the number of open pull requests awaiting the review of the user.
+func (c *GitHubGraphQLClient) CountOpenAssigned(ctx context.Context, owner, repo, login string, pullRequests bool) (int, error) {
+	query := fmt.Sprintf("repo:%s/%s is:open is:issue assignee:%s", owner, repo, login)
+	if pullRequests {
+		query = fmt.Sprintf("repo:%s/%s is:open is:pr review-requested:%s", owner, repo, login)
+	}
+
+	var r struct {
+		Search struct {
+			IssueCount int `json:"issueCount"`
+		} `json:"search"`
+	}
+	if err := c.query(ctx, issueCountQuery, map[string]any{"query": query}, &r); err != nil {
+		return 0, fmt.Errorf("failed to count items of %q: %w", login, err)
+	}
+	return r.Search.IssueCount, nil
+}
+
+const userQuery = `query($login:String!){user(login:$login){id}}`
+
+// FetchUserIDs returns the node IDs of the users in the same order.
+func (c *GitHubGraphQLClient) FetchUserIDs(ctx context.Context, logins []string) ([]string, error) {
+	var ids []string
+	for _, login := range logins {
+		var r struct {
+			User *struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		}
+		if err := c.query(ctx, userQuery, map[string]any{"login": login}, &r); err != nil {
+			return nil, fmt.Errorf("failed to fetch user %q: %w", login, err)
+		}
+		if r.User == nil {
+			return nil, fmt.Errorf("user %q not found", login)
+		}
+		ids = append(ids, r.User.ID)
+	}
+	return ids, nil
+}
+
+const addAssigneesMutation = `mutation($id:ID!, $assigneeIds:[ID!]!){
+	addAssigneesToAssignable(input:{assignableId:$id, assigneeIds:$assigneeIds}){clientMutationId}
+}`
+
+// AddAssignees assigns the users to the issue.
+func (c *GitHubGraphQLClient) AddAssignees(ctx context.Context, assignableID string, userIDs []string) error {
+	vars := map[string]any{"id": assignableID, "assigneeIds": userIDs}
+	if err := c.query(ctx, addAssigneesMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to add assignees: %w", err)
+	}
+	return nil
+}
+
+const requestReviewsMutation = `mutation($id:ID!, $userIds:[ID!]!){
+	requestReviews(input:{pullRequestId:$id, userIds:$userIds, union:true}){clientMutationId}
+}`
+
+// RequestReviews requests reviews from the users keeping the already requested reviewers.
+func (c *GitHubGraphQLClient) RequestReviews(ctx context.Context, pullRequestID string, userIDs []string) error {
+	vars := map[string]any{"id": pullRequestID, "userIds": userIDs}
+	if err := c.query(ctx, requestReviewsMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to request reviews: %w", err)
+	}
+	return nil
+}
+
+const projectQuery = `query($owner:String!, $title:String!){
+	repositoryOwner(login:$owner){
+		... on ProjectV2Owner{
+			projectsV2(first:20, query:$title){
+				nodes{
+					id title
+					fields(first:100){
+						nodes{
+							... on ProjectV2FieldCommon{id name dataType}
+							... on ProjectV2SingleSelectField{options{id name}}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+// FetchProject returns the project of the organization or the user with the exact title.
+func (c *GitHubGraphQLClient) FetchProject(ctx context.Context, owner, title string) (*projectV2, error) {
+	var r struct {
+		RepositoryOwner *struct {
+			ProjectsV2 struct {
+				Nodes []struct {
+					ID     string `json:"id"`
+					Title  string `json:"title"`
+					Fields struct {
+						Nodes []projectField `json:"nodes"`
+					} `json:"fields"`
+				} `json:"nodes"`
+			} `json:"projectsV2"`
+		} `json:"repositoryOwner"`
+	}
+
+	if err := c.query(ctx, projectQuery, map[string]any{"owner": owner, "title": title}, &r); err != nil {
+		return nil, fmt.Errorf("failed to fetch project %q: %w", title, err)
+	}
+
+	if r.RepositoryOwner != nil {
+		for _, n := range r.RepositoryOwner.ProjectsV2.Nodes {
+			if strings.EqualFold(n.Title, title) {
+				return &projectV2{ID: n.ID, Title: n.Title, Fields: n.Fields.Nodes}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("project %q of %q not found", title, owner)
+}
+
+const itemProjectsQuery = `query($id:ID!){
+	node(id:$id){
+		... on Issue{projectItems(first:100){nodes{project{id}}}}
+		... on PullRequest{projectItems(first:100){nodes{project{id}}}}
+	}
+}`
+
+// FetchItemProjects returns the IDs of the projects the issue or the pull request is in.
+func (c *GitHubGraphQLClient) FetchItemProjects(ctx context.Context, contentID string) ([]string, error) {
+	var r struct {
+		Node struct {
+			ProjectItems struct {
+				Nodes []struct {
+					Project struct {
+						ID string `json:"id"`
+					} `json:"project"`
+				} `json:"nodes"`
+			} `json:"projectItems"`
+		} `json:"node"`
+	}
+
+	if err := c.query(ctx, itemProjectsQuery, map[string]any{"id": contentID}, &r); err != nil {
+		return nil, fmt.Errorf("failed to fetch item projects: %w", err)
+	}
+
+	var ids []string
+	for _, n := range r.Node.ProjectItems.Nodes {
+		ids = append(ids, n.Project.ID)
+	}
+	return ids, nil
+}
+
+const addProjectItemMutation = `mutation($projectId:ID!, $contentId:ID!){
+	addProjectV2ItemById(input:{projectId:$projectId, contentId:$contentId}){item{id}}
+}`
+
+// AddProjectItem adds the issue or the pull request to the project and returns the ID of the project item.
+// Adding an item that is already in the project returns the existing item.
+func (c *GitHubGraphQLClient) AddProjectItem(ctx context.Context, projectID, contentID string) (string, error) {
+	var r struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
+	}
+
+	vars := map[string]any{"projectId": projectID, "contentId": contentID}
+	if err := c.query(ctx, addProjectItemMutation, vars, &r); err != nil {
+		return "", fmt.Errorf("failed to add project item: %w", err)
+	}
+	return r.AddProjectV2ItemByID.Item.ID, nil
+}
+
+const updateProjectItemFieldMutation = `mutation($projectId:ID!, $itemId:ID!, $fieldId:ID!, $value:ProjectV2FieldValue!){
+	updateProjectV2ItemFieldValue(input:{projectId:$projectId, itemId:$itemId, fieldId:$fieldId, value:$value}){clientMutationId}
+}`
+
+// UpdateProjectItemField sets the value of the field of the project item.
+func (c *GitHubGraphQLClient) UpdateProjectItemField(ctx context.Context, projectID, itemID, fieldID string, value map[string]any) error {
+	vars := map[string]any{"projectId": projectID, "itemId": itemID, "fieldId": fieldID, "value": value}
+	if err := c.query(ctx, updateProjectItemFieldMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to update project field: %w", err)
+	}
+	return nil
+}
+
+const openMilestonesQuery = `query($owner:String!, $name:String!){
+	repository(owner:$owner, name:$name){
+		milestones(first:100, states:OPEN, orderBy:{field:DUE_DATE, direction:ASC}){
+			nodes{id title description dueOn}
+		}
+	}
+}`
+
+// FetchOpenMilestones returns the open milestones of the repository.
+func (c *GitHubGraphQLClient) FetchOpenMilestones(ctx context.Context, owner, repo string) ([]milestone, error) {
+	var r struct {
+		Repository struct {
+			Milestones struct {
+				Nodes []milestone `json:"nodes"`
+			} `json:"milestones"`
+		} `json:"repository"`
+	}
+
+	if err := c.query(ctx, openMilestonesQuery, map[string]any{"owner": owner, "name": repo}, &r); err != nil {
+		return nil, fmt.Errorf("failed to fetch milestones: %w", err)
+	}
+	return r.Repository.Milestones.Nodes, nil
+}
+
+const (
+	setIssueMilestoneMutation = `mutation($id:ID!, $milestoneId:ID!){
+	updateIssue(input:{id:$id, milestoneId:$milestoneId}){clientMutationId}
+}`
+	setPullRequestMilestoneMutation = `mutation($id:ID!, $milestoneId:ID!){
+	updatePullRequest(input:{pullRequestId:$id, milestoneId:$milestoneId}){clientMutationId}
+}`
+)
+
+// SetMilestone sets the milestone of the issue or the pull request.
+func (c *GitHubGraphQLClient) SetMilestone(ctx context.Context, id, milestoneID string, pullRequest bool) error {
+	mutation := setIssueMilestoneMutation
+	if pullRequest {
+		mutation = setPullRequestMilestoneMutation
+	}
+
+	if err := c.query(ctx, mutation, map[string]any{"id": id, "milestoneId": milestoneID}, nil); err != nil {
+		return fmt.Errorf("failed to set milestone: %w", err)
+	}
+	return nil
+}
+
+const discussionCategoriesQuery = `query($owner:String!, $name:String!){
+	repository(owner:$owner, name:$name){
+		id
+		discussionCategories(first:100){nodes{id name description isAnswerable}}
+	}
+}`
+
+// FetchDiscussionCategories returns the ID of the repository and its discussion categories.
+func (c *GitHubGraphQLClient) FetchDiscussionCategories(ctx context.Context, owner, repo string) (string, []discussionCategory, error) {
+	var r struct {
+		Repository struct {
+			ID                   string `json:"id"`
+			DiscussionCategories struct {
+				Nodes []discussionCategory `json:"nodes"`
+			} `json:"discussionCategories"`
+		} `json:"repository"`
+	}
+
+	if err := c.query(ctx, discussionCategoriesQuery, map[string]any{"owner": owner, "name": repo}, &r); err != nil {
+		return "", nil, fmt.Errorf("failed to fetch discussion categories: %w", err)
+	}
+	return r.Repository.ID, r.Repository.DiscussionCategories.Nodes, nil
+}
+
+const updateDiscussionCategoryMutation = `mutation($id:ID!, $categoryId:ID!){
+	updateDiscussion(input:{discussionId:$id, categoryId:$categoryId}){clientMutationId}
+}`
+
+// UpdateDiscussionCategory moves the discussion to the category.
+func (c *GitHubGraphQLClient) UpdateDiscussionCategory(ctx context.Context, discussionID, categoryID string) error {
+	vars := map[string]any{"id": discussionID, "categoryId": categoryID}
+	if err := c.query(ctx, updateDiscussionCategoryMutation, vars, nil); err != nil {
+		return fmt.Errorf("failed to update discussion: %w", err)
+	}
+	return nil
+}
+
+const createDiscussionMutation = `mutation($repositoryId:ID!, $categoryId:ID!, $title:String!, $body:String!){
+	createDiscussion(input:{repositoryId:$repositoryId, categoryId:$categoryId, title:$title, body:$body}){discussion{url}}
+}`
+
+// CreateDiscussion creates a discussion and returns its URL.
+func (c *GitHubGraphQLClient) CreateDiscussion(ctx context.Context, repositoryID, categoryID, title, body string) (string, error) {
+	var r struct {
+		CreateDiscussion struct {
+			Discussion struct {
+				URL string `json:"url"`
+			} `json:"discussion"`
+		} `json:"createDiscussion"`
+	}
+
+	vars := map[string]any{"repositoryId": repositoryID, "categoryId": categoryID, "title": title, "body": body}
+	if err := c.query(ctx, createDiscussionMutation, vars, &r); err != nil {
+		return "", fmt.Errorf("failed to create discussion: %w", err)
+	}
+	return r.CreateDiscussion.Discussion.URL, nil
 }
 
 type request struct {
-	Query string `json:"query"`
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
 }
 
 func buildAddCommentRequest(subjectID string, body string) (string, error) {
@@ -126,6 +1143,28 @@ func (c *GitHubGraphQLClient) AddDiscussionComment(ctx context.Context, discussi
 	return nil
 }
 
+// query sends the query with the variables and decodes the data of the response into out.
+func (c *GitHubGraphQLClient) query(ctx context.Context, query string, vars map[string]any, out any) error {
+	b, err := json.Marshal(request{Query: query, Variables: vars})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	data, err := c.request(ctx, string(b))
+	if err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
 func (c *GitHubGraphQLClient) request(ctx context.Context, payload string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, strings.NewReader(payload))
 	if err != nil {
@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,7 +25,8 @@ func TestFetchRepoLabels(t *testing.T) {
           {
             "id": "MDU6TGFiZWw1NTU0NDg4MA==",
             "name": "bug",
-            "description": "Something isn't working"
+            "description": "Something isn't working",
+            "color": "d73a4a"
           },
           {
             "id": "MDU6TGFiZWw1NTU0NDg4MQ==",
@@ -43,7 +48,7 @@ func TestFetchRepoLabels(t *testing.T) {
 		require.NoError(t, err)
 
 		expected := []Label{
-			{ID: "MDU6TGFiZWw1NTU0NDg4MA==", Name: "bug", Description: "Something isn't working"},
+			{ID: "MDU6TGFiZWw1NTU0NDg4MA==", Name: "bug", Description: "Something isn't working", Color: "d73a4a"},
 			{ID: "MDU6TGFiZWw1NTU0NDg4MQ==", Name: "enhancement", Description: "New feature or request"},
 			{ID: "MDU6TGFiZWw1NTU0NDg4Mg==", Name: "question", Description: "Further information is requested"},
 		}
@@ -241,3 +246,267 @@ func newFakeGhClient(statusCode int, response string) *GitHubGraphQLClient {
 	client := NewGithubClient("token", "url", httpClient)
 	return client
 }
+
+func TestFetchRepoLabelsPagination(t *testing.T) {
+	transport := &sequenceTransport{
+		responses: []string{
+			`{"data":{"repository":{"labels":{"pageInfo":{"hasNextPage":true,"endCursor":"cursor1"},"nodes":[{"id":"1","name":"bug"}]}}}}`,
+			`{"data":{"repository":{"labels":{"pageInfo":{"hasNextPage":false,"endCursor":"cursor2"},"nodes":[{"id":"2","name":"enhancement"}]}}}}`,
+		},
+	}
+	client := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+	labels, err := client.FetchRepoLabels(context.TODO(), "owner", "repo")
+	require.NoError(t, err)
+
+	assert.Equal(t, []Label{{ID: "1", Name: "bug"}, {ID: "2", Name: "enhancement"}}, labels)
+	require.Len(t, transport.requests, 2)
+	assert.Contains(t, transport.requests[0], "after:null")
+	assert.Contains(t, transport.requests[1], `after:\"cursor1\"`)
+}
+
+func TestFetchClosedIssues(t *testing.T) {
+	fakeResponse := `{
+  "data": {
+    "repository": {
+      "issues": {
+        "nodes": [
+          {
+            "id": "I_1",
+            "number": 1,
+            "title": "Crash",
+            "body": "It crashes",
+            "labels": {"nodes": [{"name": "bug"}]},
+            "timelineItems": {
+              "nodes": [
+                {"__typename": "LabeledEvent", "actor": {"login": "bot"}, "label": {"name": "bug"}, "createdAt": "2024-01-01T00:00:00Z"},
+                {"__typename": "UnlabeledEvent", "actor": {"login": "maintainer"}, "label": {"name": "bug"}, "createdAt": "2024-01-02T00:00:00Z"},
+                {"__typename": "LabeledEvent", "actor": {"login": "maintainer"}, "label": {"name": "bug"}, "createdAt": "2024-01-03T00:00:00Z"}
+              ]
+            }
+          }
+        ]
+      }
+    }
+  }
+}`
+	client := newFakeGhClient(200, fakeResponse)
+	items, err := client.FetchClosedIssues(context.TODO(), "owner", "repo", 10)
+	require.NoError(t, err)
+
+	expected := []labeledItem{
+		{
+			Number: 1,
+			Title:  "Crash",
+			Body:   "It crashes",
+			Labels: []appliedLabel{
+				{Name: "bug", Actor: "maintainer", AppliedAt: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	}
+	assert.Equal(t, expected, items)
+}
+
+func TestFetchClosedIssuesPagination(t *testing.T) {
+	page := func(n int, hasNext bool) string {
+		nodes := strings.TrimSuffix(strings.Repeat(`{"title":"Issue"},`, n), ",")
+		return fmt.Sprintf(`{"data":{"repository":{"issues":{"pageInfo":{"hasNextPage":%t,"endCursor":"c1"},"nodes":[%s]}}}}`, hasNext, nodes)
+	}
+	transport := &sequenceTransport{responses: []string{page(100, true), page(50, true)}}
+	client := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+	items, err := client.FetchClosedIssues(context.TODO(), "owner", "repo", 150)
+	require.NoError(t, err)
+	assert.Len(t, items, 150)
+	require.Len(t, transport.requests, 2)
+	assert.Contains(t, transport.requests[0], `"first":100`)
+	assert.Contains(t, transport.requests[1], `"first":50`)
+	assert.Contains(t, transport.requests[1], `"after":"c1"`)
+}
+
+func TestBuildLabeledItemsQuery(t *testing.T) {
+	assert.Contains(t, buildLabeledItemsQuery(kindPullRequests), "pullRequests(first:$first")
+	assert.Contains(t, buildLabeledItemsQuery(kindIssues), "timelineItems")
+	assert.NotContains(t, buildLabeledItemsQuery(kindDiscussions), "timelineItems")
+	assert.Contains(t, buildLabeledItemsQuery(kindDiscussions), "comments(last:50)")
+}
+
+func TestFetchLabelEvents(t *testing.T) {
+	fakeResponse := `{
+  "data": {
+    "node": {
+      "timelineItems": {
+        "nodes": [
+          {"__typename": "UnlabeledEvent", "actor": {"login": "maintainer"}, "label": {"name": "bug"}, "createdAt": "2024-01-01T00:00:00Z"}
+        ]
+      }
+    }
+  }
+}`
+	client := newFakeGhClient(200, fakeResponse)
+	events, err := client.FetchLabelEvents(context.TODO(), "I_1")
+	require.NoError(t, err)
+
+	expected := []gqlLabelEvent{
+		{
+			Typename:  "UnlabeledEvent",
+			Actor:     &actor{Login: "maintainer"},
+			Label:     Label{Name: "bug"},
+			CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	assert.Equal(t, expected, events)
+}
+
+func TestFetchPermission(t *testing.T) {
+	fakeResponse := `{
+  "data": {
+    "repository": {
+      "collaborators": {
+        "edges": [
+          {"permission": "READ", "node": {"login": "maintainer-bot"}},
+          {"permission": "TRIAGE", "node": {"login": "Maintainer"}}
+        ]
+      }
+    }
+  }
+}`
+	client := newFakeGhClient(200, fakeResponse)
+
+	permission, err := client.FetchPermission(context.TODO(), "owner", "repo", "maintainer")
+	require.NoError(t, err)
+	assert.Equal(t, "TRIAGE", permission)
+
+	permission, err = client.FetchPermission(context.TODO(), "owner", "repo", "stranger")
+	require.NoError(t, err)
+	assert.Empty(t, permission)
+}
+
+func TestAddReaction(t *testing.T) {
+	transport := &sequenceTransport{
+		responses: []string{`{"data":{"addReaction":{"clientMutationId":null}}}`},
+	}
+	client := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+	require.NoError(t, client.AddReaction(context.TODO(), "IC_1", "EYES"))
+	require.Len(t, transport.requests, 1)
+	assert.Contains(t, transport.requests[0], `"variables":{"content":"EYES","subjectId":"IC_1"}`)
+}
+
+func TestFetchBotComment(t *testing.T) {
+	marker := commentMarker{Hash: "abc", Labels: []string{"bug"}}
+
+	t.Run("found", func(t *testing.T) {
+		response, err := json.Marshal(map[string]any{
+			"data": map[string]any{
+				"node": map[string]any{
+					"comments": map[string]any{
+						"nodes": []map[string]any{
+							{"id": "IC_1", "body": "old " + marker.String(), "viewerDidAuthor": true},
+							{"id": "IC_2", "body": "spoofed " + marker.String(), "viewerDidAuthor": false},
+							{"id": "IC_3", "body": "without marker", "viewerDidAuthor": true},
+						},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		client := newFakeGhClient(200, string(response))
+		comment, err := client.FetchBotComment(context.TODO(), "I_1")
+		require.NoError(t, err)
+		require.NotNil(t, comment)
+		assert.Equal(t, "IC_1", comment.ID)
+		assert.Equal(t, marker, comment.Marker)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		client := newFakeGhClient(200, `{"data":{"node":{"comments":{"nodes":[]}}}}`)
+		comment, err := client.FetchBotComment(context.TODO(), "I_1")
+		require.NoError(t, err)
+		assert.Nil(t, comment)
+	})
+}
+
+func TestFetchFile(t *testing.T) {
+	t.Run("exists", func(t *testing.T) {
+		client := newFakeGhClient(200, `{"data":{"repository":{"defaultBranchRef":{"name":"main","target":{"oid":"abc"}},"object":{"text":"line\n"}}}}`)
+		file, err := client.FetchFile(context.TODO(), "owner", "repo", "corrections.jsonl")
+		require.NoError(t, err)
+		assert.Equal(t, repoFile{Branch: "main", HeadOID: "abc", Text: "line\n"}, file)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		client := newFakeGhClient(200, `{"data":{"repository":{"defaultBranchRef":{"name":"main","target":{"oid":"abc"}},"object":null}}}`)
+		file, err := client.FetchFile(context.TODO(), "owner", "repo", "corrections.jsonl")
+		require.NoError(t, err)
+		assert.Empty(t, file.Text)
+	})
+}
+
+func TestCommitFile(t *testing.T) {
+	transport := &sequenceTransport{
+		responses: []string{`{"data":{"createCommitOnBranch":{"commit":{"oid":"def"}}}}`},
+	}
+	client := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+	base := repoFile{Branch: "main", HeadOID: "abc"}
+	require.NoError(t, client.CommitFile(context.TODO(), "owner", "repo", base, "a.jsonl", []byte("{}\n"), "Update"))
+	require.Len(t, transport.requests, 1)
+	assert.Contains(t, transport.requests[0], `"expectedHeadOid":"abc"`)
+	assert.Contains(t, transport.requests[0], `"contents":"e30K"`)
+	assert.Contains(t, transport.requests[0], `"repositoryNameWithOwner":"owner/repo"`)
+}
+
+func TestSearchIssues(t *testing.T) {
+	client := newFakeGhClient(200, `{"data":{"search":{"nodes":[
+		{"id":"I_1","number":1,"url":"https://github.com/owner/repo/issues/1","title":"Crash","body":"Body","state":"OPEN"},
+		{}
+	]}}}`)
+
+	issues, err := client.SearchIssues(context.TODO(), "repo:owner/repo is:issue crash", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []issueSummary{
+		{ID: "I_1", Number: 1, URL: "https://github.com/owner/repo/issues/1", Title: "Crash", Body: "Body", State: "OPEN"},
+	}, issues)
+}
+
+func TestFetchTeamMembers(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		client := newFakeGhClient(200, `{"data":{"organization":{"team":{"members":{"nodes":[{"login":"alice"},{"login":"bob"}]}}}}}`)
+		members, err := client.FetchTeamMembers(context.TODO(), "org", "cli")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob"}, members)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		client := newFakeGhClient(200, `{"data":{"organization":{"team":null}}}`)
+		_, err := client.FetchTeamMembers(context.TODO(), "org", "missing")
+		require.Error(t, err)
+	})
+}
+
+func TestCountOpenAssigned(t *testing.T) {
+	transport := &sequenceTransport{responses: []string{`{"data":{"search":{"issueCount":3}}}`}}
+	client := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+	n, err := client.CountOpenAssigned(context.TODO(), "owner", "repo", "alice", true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Contains(t, transport.requests[0], "review-requested:alice")
+}
+
+func TestFetchProjectNotFound(t *testing.T) {
+	client := newFakeGhClient(200, `{"data":{"repositoryOwner":null}}`)
+	_, err := client.FetchProject(context.TODO(), "owner", "Triage")
+	require.Error(t, err)
+}
+
+func TestFetchDiscussionCategories(t *testing.T) {
+	client := newFakeGhClient(200, `{"data":{"repository":{"id":"R_1","discussionCategories":{"nodes":[{"id":"C_1","name":"Q&A","isAnswerable":true}]}}}}`)
+
+	repoID, categories, err := client.FetchDiscussionCategories(context.TODO(), "owner", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, "R_1", repoID)
+	assert.Equal(t, []discussionCategory{{ID: "C_1", Name: "Q&A", IsAnswerable: true}}, categories)
+}
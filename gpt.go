@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -13,6 +15,9 @@ import (
 type labelingAssistant struct {
 	model  string
 	client *openai.Client
+	budget tokenBudget
+	// embeddingTokens counts the tokens embedded by the assistant, as they are paid too.
+	embeddingTokens *int
 }
 
 func newLabelingAssistant(token string, model string, httpClient *http.Client) *labelingAssistant {
@@ -23,13 +28,16 @@ func newLabelingAssistant(token string, model string, httpClient *http.Client) *
 	clientCfg := openai.DefaultConfig(token)
 	clientCfg.HTTPClient = httpClient
 	client := openai.NewClientWithConfig(clientCfg)
-	return &labelingAssistant{client: client, model: model}
+	return &labelingAssistant{client: client, model: model, budget: newTokenBudget(model, 0), embeddingTokens: new(int)}
 }
 
 type getLabelsRequest struct {
-	labels  string
-	payload string
-	details string
+	labels   string
+	payload  string
+	details  string
+	examples string
+	// mistakes are recent corrections of the labels chosen by the action
+	mistakes string
 }
 
 type chosenLabel struct {
@@ -41,6 +49,8 @@ type chosenLabel struct {
 type getLabelsResponse struct {
 	Labels      []chosenLabel `json:"labels"`
 	Explanation string        `json:"explanation"`
+
+	usage openai.Usage
 }
 
 func (r getLabelsResponse) labelIDs() []string {
@@ -51,10 +61,63 @@ func (r getLabelsResponse) labelIDs() []string {
 	return ids
 }
 
+func (r getLabelsResponse) labelNames() []string {
+	var names []string
+	for _, label := range r.Labels {
+		names = append(names, label.Name)
+	}
+	return names
+}
+
+// without returns the response without the labels with the given names.
+func (r getLabelsResponse) without(names []string) getLabelsResponse {
+	r.Labels = slices.DeleteFunc(slices.Clone(r.Labels), func(l chosenLabel) bool {
+		return slices.ContainsFunc(names, func(name string) bool {
+			return strings.EqualFold(l.Name, name)
+		})
+	})
+	return r
+}
+
+// has reports whether the label is chosen.
+func (r getLabelsResponse) has(name string) bool {
+	return slices.ContainsFunc(r.Labels, func(l chosenLabel) bool { return strings.EqualFold(l.Name, name) })
+}
+
+// with returns the response with the label added if it is available and not chosen yet.
+func (r getLabelsResponse) with(available []Label, name, explanation string) getLabelsResponse {
+	if r.has(name) {
+		return r
+	}
+
+	idx := slices.IndexFunc(available, func(l Label) bool { return strings.EqualFold(l.Name, name) })
+	if idx < 0 {
+		return r
+	}
+
+	r.Labels = append(slices.Clone(r.Labels), chosenLabel{
+		ID:          available[idx].ID,
+		Name:        available[idx].Name,
+		Explanation: explanation,
+	})
+	return r
+}
+
 var ErrEmptyMessage = errors.New("empty message")
 
 func (a labelingAssistant) GetLabels(ctx context.Context, request getLabelsRequest) (getLabelsResponse, error) {
-	prompt := buildPrompt(request.payload, request.labels, request.details)
+	var resp getLabelsResponse
+	usage, err := a.completeJSON(ctx, a.buildPrompt(request), &resp)
+	if err != nil {
+		return getLabelsResponse{}, err
+	}
+	resp.usage = usage
+
+	return resp, nil
+}
+
+// completeJSON sends the prompt to the model and decodes its JSON answer into out.
+func (a labelingAssistant) completeJSON(ctx context.Context, prompt []openai.ChatCompletionMessage, out any) (openai.Usage, error) {
 	chatResponse, err := a.client.CreateChatCompletion(
 		ctx,
 		openai.ChatCompletionRequest{
@@ -63,27 +126,33 @@ func (a labelingAssistant) GetLabels(ctx context.Context, request getLabelsReque
 		},
 	)
 	if err != nil {
-		return getLabelsResponse{}, fmt.Errorf("failed to create completion: %w", err)
+		return openai.Usage{}, fmt.Errorf("failed to create completion: %w", err)
 	}
 
 	msg := chatResponse.Choices[0].Message.Content
 	if msg == "" {
-		return getLabelsResponse{}, ErrEmptyMessage
+		return openai.Usage{}, ErrEmptyMessage
 	}
 
-	var resp getLabelsResponse
-	if err := json.Unmarshal([]byte(msg), &resp); err != nil {
-		return getLabelsResponse{}, fmt.Errorf("failed to unmarshal message: %w", err)
+	if err := json.Unmarshal([]byte(msg), out); err != nil {
+		return openai.Usage{}, fmt.Errorf("failed to unmarshal message: %w", err)
 	}
-
-	return resp, nil
+	return chatResponse.Usage, nil
 }
 
-func buildPrompt(payload, labels, details string) []openai.ChatCompletionMessage {
+func (a labelingAssistant) buildPrompt(request getLabelsRequest) []openai.ChatCompletionMessage {
+	// the prompt is built with the truncation note so that
+	// the note itself is taken into account in the budget
+	systemPrompt := buildSystemPrompt(request, true)
+	payload, truncated := truncateText(request.payload, a.budget.body(systemPrompt))
+	if !truncated {
+		systemPrompt = buildSystemPrompt(request, false)
+	}
+
 	return []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
-			Content: buildSystemPrompt(labels, details),
+			Content: systemPrompt,
 		},
 		{
 			Role:    openai.ChatMessageRoleSystem,
@@ -92,7 +161,7 @@ func buildPrompt(payload, labels, details string) []openai.ChatCompletionMessage
 	}
 }
 
-func buildSystemPrompt(labels string, details string) string {
+func buildSystemPrompt(request getLabelsRequest, truncated bool) string {
 	systemPrompt := `You are the developer.
 Your task is to triage discussions on GitHub by defining labels for discussions. You must analyse the title and content of the discussion and assign it one or more of the available labels.
 You will receive discussions in the following format:
@@ -102,10 +171,19 @@ Body: Some body
 
 Consider the context of the discussion title and text when assigning labels.
 `
-	if details != "" {
-		systemPrompt += fmt.Sprintf("Also consider the details when assigning labels:\n%s\n", details)
+	if truncated {
+		systemPrompt += "The body was too long and has been truncated: long log excerpts are collapsed and only the beginning and the end of the text are kept.\n"
+	}
+	if request.details != "" {
+		systemPrompt += fmt.Sprintf("Also consider the details when assigning labels:\n%s\n", request.details)
+	}
+	systemPrompt += fmt.Sprintf("The following labels are available to you in json format:\n%s\n", request.labels)
+	if request.examples != "" {
+		systemPrompt += fmt.Sprintf("Here are discussions from this repository that were labeled by maintainers. Follow the same conventions:\n\n%s", request.examples)
+	}
+	if request.mistakes != "" {
+		systemPrompt += fmt.Sprintf("Maintainers corrected the labels previously chosen for other discussions. Previous mistakes to avoid:\n%s", request.mistakes)
 	}
-	systemPrompt += fmt.Sprintf("The following labels are available to you in json format:\n%s\n", labels)
 	systemPrompt += `Provide the answer as json. For example:
 {
   "labels": [
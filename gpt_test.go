@@ -11,6 +11,18 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// fakeAssistant returns an assistant whose model always answers with the content.
+func fakeAssistant(t *testing.T, content string) *labelingAssistant {
+	resp := openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: content}}},
+	}
+	b, err := json.Marshal(resp)
+	require.NoError(t, err)
+
+	httpClient := &http.Client{Transport: &fakeTransport{statusCode: 200, response: string(b)}}
+	return newLabelingAssistant("key", openai.GPT3Dot5Turbo, httpClient)
+}
+
 func TestGetLabels(t *testing.T) {
 	t.Parallel()
 
@@ -69,3 +81,36 @@ func TestGetLabels(t *testing.T) {
 		assert.ErrorIs(t, err, ErrEmptyMessage)
 	})
 }
+
+func TestGetLabelsResponseWithout(t *testing.T) {
+	resp := getLabelsResponse{
+		Labels: []chosenLabel{
+			{ID: "1", Name: "bug"},
+			{ID: "2", Name: "question"},
+		},
+		Explanation: "explanation",
+	}
+
+	expected := getLabelsResponse{
+		Labels:      []chosenLabel{{ID: "2", Name: "question"}},
+		Explanation: "explanation",
+	}
+	assert.Equal(t, expected, resp.without([]string{"Bug"}))
+	assert.Len(t, resp.Labels, 2)
+}
+
+func TestGetLabelsResponseWith(t *testing.T) {
+	available := []Label{{ID: "1", Name: "bug"}, {ID: "2", Name: "duplicate"}}
+	r := getLabelsResponse{Labels: []chosenLabel{{ID: "1", Name: "bug"}}}
+
+	assert.Equal(t, []string{"bug", "duplicate"}, r.with(available, "duplicate", "Duplicate.").labelNames())
+	assert.Equal(t, []string{"bug"}, r.with(available, "Bug", "Bug.").labelNames())
+	assert.Equal(t, []string{"bug"}, r.with(available, "missing", "Missing.").labelNames())
+	assert.Len(t, r.Labels, 1)
+}
+
+func TestGetLabelsResponseHas(t *testing.T) {
+	r := getLabelsResponse{Labels: []chosenLabel{{Name: "bug"}}}
+	assert.True(t, r.has("Bug"))
+	assert.False(t, r.has("duplicate"))
+}
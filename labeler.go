@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// labeler runs the labeling pipeline: it narrows down the candidate labels,
+// collects few-shot examples and asks the model to choose labels.
+type labeler struct {
+	cfg       config
+	ghapi     *GitHubGraphQLClient
+	assistant *labelingAssistant
+
+	// history is loaded once and reused for every classified item
+	history []fewShotExample
+}
+
+func newLabeler(cfg config, ghapi *GitHubGraphQLClient) *labeler {
+	assistant := newLabelingAssistant(cfg.gptToken, cfg.gptModel, nil)
+	assistant.budget = newTokenBudget(cfg.gptModel, cfg.maxPromptTokens)
+
+	return &labeler{
+		cfg:       cfg,
+		ghapi:     ghapi,
+		assistant: assistant,
+	}
+}
+
+func (l *labeler) classify(ctx context.Context, availableLabels []Label, p payload) (getLabelsResponse, error) {
+	var err error
+	if l.cfg.maxCandidates > 0 {
+		availableLabels, err = l.preselectCandidates(ctx, availableLabels, p)
+		if err != nil {
+			return getLabelsResponse{}, err
+		}
+	}
+
+	labels, err := marshalLabels(availableLabels, l.assistant.budget.labels())
+	if err != nil {
+		return getLabelsResponse{}, fmt.Errorf("failed to marshal labels: %w", err)
+	}
+
+	var examples string
+	if l.cfg.fewShotCount > 0 {
+		examples, err = l.fewShotExamples(ctx, p, availableLabels)
+		if err != nil {
+			return getLabelsResponse{}, err
+		}
+	}
+
+	var mistakes string
+	if l.cfg.correctionsStore != "" && l.cfg.correctionsExamples > 0 {
+		mistakes, err = l.recentMistakes(ctx)
+		if err != nil {
+			return getLabelsResponse{}, err
+		}
+	}
+
+	return l.assistant.GetLabels(ctx, getLabelsRequest{
+		labels:   labels,
+		payload:  p.String(),
+		details:  l.cfg.details,
+		examples: examples,
+		mistakes: mistakes,
+	})
+}
+
+func (l *labeler) recentMistakes(ctx context.Context) (string, error) {
+	store, err := newCorrectionStore(l.cfg, l.ghapi)
+	if err != nil {
+		return "", err
+	}
+
+	corrections, err := store.Recent(ctx, l.cfg.correctionsExamples)
+	if err != nil {
+		return "", err
+	}
+	return formatMistakes(corrections), nil
+}
+
+func (l *labeler) preselectCandidates(ctx context.Context, labels []Label, p payload) ([]Label, error) {
+	cfg := l.cfg
+
+	cache, err := loadEmbeddingCache(cfg.embeddingsCache)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := preselectLabels(ctx, l.assistant.Embed, labels, p.String(), cfg.maxCandidates, cfg.alwaysIncluded, cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preselect labels: %w", err)
+	}
+
+	if err := cache.save(cfg.embeddingsCache); err != nil {
+		log.Printf("Failed to save embedding cache: %s", err)
+	}
+
+	return candidates, nil
+}
+
+// fewShotExamples renders the examples most similar to the item. The examples show only the candidate labels,
+// so that the model is not taught to choose labels it is not offered.
+func (l *labeler) fewShotExamples(ctx context.Context, p payload, candidateLabels []Label) (string, error) {
+	if l.history == nil {
+		items, err := l.ghapi.FetchClosedIssues(ctx, l.cfg.repoOwner, l.cfg.repoName, fewShotHistory)
+		if err != nil {
+			return "", err
+		}
+		l.history = examplesFromItems(items, resolveBotLogin(ctx, l.ghapi))
+	}
+
+	// the item itself must not be its own example
+	var candidates []fewShotExample
+	for _, e := range restrictExamples(l.history, candidateLabels) {
+		if e.Title != p.title {
+			candidates = append(candidates, e)
+		}
+	}
+
+	var (
+		embed embedFunc
+		cache embeddingCache
+	)
+	if l.cfg.fewShotBy == similarityEmbedding {
+		embed = l.assistant.Embed
+
+		var err error
+		if cache, err = loadEmbeddingCache(l.cfg.embeddingsCache); err != nil {
+			return "", err
+		}
+	}
+
+	examples, err := selectExamples(ctx, embed, cache, candidates, p.String(), l.cfg.fewShotCount)
+	if err != nil {
+		return "", err
+	}
+
+	if cache != nil {
+		if err := cache.save(l.cfg.embeddingsCache); err != nil {
+			log.Printf("Failed to save embedding cache: %s", err)
+		}
+	}
+
+	return formatExamples(examples, l.cfg.fewShotTokens), nil
+}
+
+// findDuplicate searches for issues similar to the item and asks the model whether it duplicates one of them.
+// It returns the section of the comment or an empty string if no duplicate is found.
+func (l *labeler) findDuplicate(ctx context.Context, p payload) (string, error) {
+	query := duplicateSearchQuery(l.cfg.repoOwner, l.cfg.repoName, p.title)
+	found, err := l.ghapi.SearchIssues(ctx, query, duplicateSearchResults)
+	if err != nil {
+		return "", err
+	}
+
+	found = slices.DeleteFunc(found, func(i issueSummary) bool {
+		return i.ID == p.nodeID
+	})
+	if len(found) == 0 {
+		return "", nil
+	}
+
+	var embed embedFunc
+	if l.cfg.duplicateRerank {
+		embed = l.assistant.Embed
+	}
+
+	candidates, err := rankIssues(ctx, embed, found, p.String(), duplicateCandidates)
+	if err != nil {
+		return "", err
+	}
+
+	verdict, err := l.assistant.FindDuplicate(ctx, p.String(), candidates)
+	if err != nil {
+		return "", err
+	}
+
+	original, ok := verdict.duplicateOf(candidates, l.cfg.duplicateConfidence)
+	if !ok {
+		log.Printf("No duplicate found (confidence %.2f).", verdict.Confidence)
+		return "", nil
+	}
+
+	log.Printf("The issue duplicates #%d (confidence %.2f).", original.Number, verdict.Confidence)
+	return formatDuplicates(verdict, original, candidates), nil
+}
+
+// resolveBotLogin returns the login under which the action acts.
+func resolveBotLogin(ctx context.Context, ghapi *GitHubGraphQLClient) string {
+	login, err := ghapi.FetchViewerLogin(ctx)
+	if err != nil || login == "" {
+		return defaultBotLogin
+	}
+	return login
+}
+
+// vetoedLabels returns the labels that a human removed from the item and did not add back.
+// The bot must not apply them again.
+func vetoedLabels(events []gqlLabelEvent, botLogin string) []string {
+	vetoed := make(map[string]bool)
+	var order []string
+
+	for _, e := range events {
+		if e.Actor == nil || isBotActor(e.Actor.Login, botLogin) {
+			continue
+		}
+
+		switch e.Typename {
+		case "UnlabeledEvent":
+			if _, seen := vetoed[e.Label.Name]; !seen {
+				order = append(order, e.Label.Name)
+			}
+			vetoed[e.Label.Name] = true
+		case "LabeledEvent":
+			if vetoed[e.Label.Name] {
+				vetoed[e.Label.Name] = false
+			}
+		}
+	}
+
+	var labels []string
+	for _, name := range order {
+		if vetoed[name] {
+			labels = append(labels, name)
+		}
+	}
+	return labels
+}
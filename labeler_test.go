@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVetoedLabels(t *testing.T) {
+	event := func(typename, login, label string) gqlLabelEvent {
+		return gqlLabelEvent{Typename: typename, Actor: &actor{Login: login}, Label: Label{Name: label}}
+	}
+
+	events := []gqlLabelEvent{
+		event("LabeledEvent", "auto-labeler", "bug"),
+		event("UnlabeledEvent", "maintainer", "bug"),
+		// the bot must not undo the veto
+		event("LabeledEvent", "auto-labeler", "bug"),
+		event("LabeledEvent", "auto-labeler", "question"),
+		event("UnlabeledEvent", "maintainer", "question"),
+		event("LabeledEvent", "maintainer", "question"),
+		event("UnlabeledEvent", "auto-labeler", "enhancement"),
+		event("UnlabeledEvent", "dependabot[bot]", "dependencies"),
+		{Typename: "UnlabeledEvent", Label: Label{Name: "ghost"}},
+	}
+
+	assert.Equal(t, []string{"bug"}, vetoedLabels(events, "auto-labeler"))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	languagePrefix = "lang/"
+
+	english = "en"
+
+	// translationTokens limits the text to translate, so that the translation fits into the tokens
+	// reserved for the answer along with the rest of the json.
+	translationTokens = responseTokens * 2 / 3
+)
+
+// languageVerdict is the language of the item and its translation to English.
+type languageVerdict struct {
+	// Language is an ISO 639-1 code, e.g. "zh", "ru" or "pt".
+	Language string `json:"language"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	// truncated is set if only the beginning of the item was translated.
+	truncated bool
+}
+
+func (v languageVerdict) english() bool {
+	return v.Language == "" || strings.EqualFold(v.Language, english)
+}
+
+func (v languageVerdict) label() string {
+	return languagePrefix + strings.ToLower(v.Language)
+}
+
+// DetectLanguage asks the model for the language of the item and, if translate is set, for its English translation.
+func (a labelingAssistant) DetectLanguage(ctx context.Context, payload string, translate bool) (languageVerdict, error) {
+	systemPrompt := `You are the developer triaging issues on GitHub.
+You will receive an issue. Detect the language it is written in, ignoring code, logs and links.
+`
+	if translate {
+		systemPrompt += `If it is not English, translate the title and the body to English keeping the Markdown formatting, code and logs as is.
+
+Provide the answer as json. For example:
+{
+  "language": "pt",
+  "title": "Crash on startup",
+  "body": "The application crashes when ..."
+}
+
+The "language" field is an ISO 639-1 code. The "title" and "body" fields are empty if the issue is in English.
+`
+	} else {
+		systemPrompt += `
+Provide the answer as json. For example:
+{
+  "language": "pt"
+}
+
+The "language" field is an ISO 639-1 code.
+`
+	}
+
+	budget := a.budget.body(systemPrompt) / 2
+	if translate {
+		budget = min(budget, translationTokens)
+	}
+	text, truncated := truncateText(payload, budget)
+
+	var verdict languageVerdict
+	_, err := a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: text},
+	}, &verdict)
+	if err != nil {
+		return languageVerdict{}, fmt.Errorf("failed to detect language: %w", err)
+	}
+	verdict.truncated = truncated
+	return verdict, nil
+}
+
+// formatTranslation renders the translation as a collapsible comment section.
+func formatTranslation(v languageVerdict) string {
+	var note string
+	if v.truncated {
+		note = "\n*The text is too long, only its beginning is translated.*\n"
+	}
+	return fmt.Sprintf("<details>\n<summary>English translation (from %q)</summary>\n\n**%s**\n\n%s\n%s</details>\n",
+		v.Language, v.Title, v.Body, note)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageVerdict(t *testing.T) {
+	assert.True(t, languageVerdict{}.english())
+	assert.True(t, languageVerdict{Language: "EN"}.english())
+	assert.False(t, languageVerdict{Language: "pt"}.english())
+	assert.Equal(t, "lang/zh", languageVerdict{Language: "ZH"}.label())
+}
+
+func TestDetectLanguage(t *testing.T) {
+	assistant := fakeAssistant(t, `{"language":"ru","title":"Crash","body":"It crashes."}`)
+
+	verdict, err := assistant.DetectLanguage(context.TODO(), "Title: Падение\nBody: Оно падает.", true)
+	require.NoError(t, err)
+	assert.Equal(t, languageVerdict{Language: "ru", Title: "Crash", Body: "It crashes."}, verdict)
+
+	assert.Equal(t,
+		"<details>\n<summary>English translation (from \"ru\")</summary>\n\n**Crash**\n\nIt crashes.\n</details>\n",
+		formatTranslation(verdict),
+	)
+
+	t.Run("long text", func(t *testing.T) {
+		verdict, err := assistant.DetectLanguage(context.TODO(), strings.Repeat("Оно падает. ", 2000), true)
+		require.NoError(t, err)
+		assert.True(t, verdict.truncated)
+		assert.Contains(t, formatTranslation(verdict), "only its beginning is translated")
+	})
+}
@@ -34,25 +34,81 @@ type config struct {
 	excludedLabels  []string
 	gptToken        string
 	gptModel        string
-	ghToken         string
-	graphQLEndpoint string
-	repoOwner       string
-	repoName        string
+	maxPromptTokens int
+	maxCandidates   int
+	alwaysIncluded  []string
+	embeddingsCache string
+	fewShotCount    int
+	fewShotTokens   int
+	fewShotBy       string
+	existingLabels  string
+	labelGroups     []string
+	configPath      string
+	mode            string
+	// correctionsStore is where label corrections are recorded, empty if they are not
+	correctionsStore    string
+	correctionsPath     string
+	correctionsExamples int
+	detectDuplicates    bool
+	duplicateLabel      string
+	duplicateConfidence float64
+	duplicateRerank     bool
+	requiredInfo        []string
+	needsInfoLabel      string
+	issueTemplates      string
+	priority            bool
+	detectSecurity      bool
+	securityLabel       string
+	securityConfidence  float64
+	// securityWebhook is notified about security reports instead of commenting on them
+	securityWebhook string
+	moderate        bool
+	spamLabel       string
+	spamConfidence  float64
+	spamActions     []string
+	languageLabel   bool
+	// discussionCategory moves discussions to the category chosen by the model
+	discussionCategory bool
+	categoryConfidence float64
+	// convertQuestions moves issues that are questions to Q&A discussions
+	convertQuestions   bool
+	questionConfidence float64
+	qaCategory         string
+	translate          bool
+	ghToken            string
+	graphQLEndpoint    string
+	repoOwner          string
+	repoName           string
 }
 
 const (
 	defaultTimeoutS = 60
 
+	defaultGraphQLEndpoint = "https://api.github.com/graphql"
+
+	defaultEmbeddingsCache = ".auto-label/embeddings.json"
+
+	defaultFewShotTokens = 1000
+	// fewShotHistory is the number of recently closed issues the examples are chosen from.
+	fewShotHistory = 100
+
+	// defaultBotLogin is the login of the GITHUB_TOKEN owner, which cannot query the viewer.
+	defaultBotLogin = "github-actions[bot]"
+
 	discussionsLink = "https://github.com/nikpivkin/auto-label/discussions"
 	issuesLink      = "https://github.com/nikpivkin/auto-label/issues"
 )
 
 func main() {
 
-	timeout := flag.Int("timeout", defaultTimeoutS, fmt.Sprintf("timeout in seconds (default %ds)", defaultTimeoutS))
-	gptModel := flag.String("gpt-model", openai.GPT3Dot5Turbo, fmt.Sprintf("the chat-gpt model used (default %s)", openai.GPT3Dot5Turbo))
-	details := flag.String("details", "", "additional details for label suggestions")
-	excludedLabels := flag.String("excluded-labels", "", "a comma-separated list of labels to exclude from automatic assignment. For example: 'bug,duplicate'")
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	applyFlags := registerFlags(flag.CommandLine)
 
 	flag.Parse()
 
@@ -60,37 +116,198 @@ func main() {
 	parts := strings.Split(ghRepo, "/")
 
 	c := config{
-		timeout:         *timeout,
 		eventName:       envOrFatal("GITHUB_EVENT_NAME"),
 		eventPath:       envOrFatal("GITHUB_EVENT_PATH"),
-		details:         *details,
-		excludedLabels:  strings.Split(*excludedLabels, ","),
 		gptToken:        envOrFatal("OPENAI_API_KEY"),
-		gptModel:        *gptModel,
 		ghToken:         envOrFatal("GITHUB_TOKEN"),
 		graphQLEndpoint: envOrFatal("GITHUB_GRAPHQL_URL"),
 		repoOwner:       parts[0],
 		repoName:        parts[1],
+		securityWebhook: os.Getenv("SECURITY_WEBHOOK_URL"),
 	}
+	applyFlags(&c)
 
 	if err := run(c); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// registerFlags defines the flags that configure the labeling and returns
+// a function that copies the parsed values into the config.
+func registerFlags(fs *flag.FlagSet) func(*config) {
+	timeout := fs.Int("timeout", defaultTimeoutS, fmt.Sprintf("timeout in seconds (default %ds)", defaultTimeoutS))
+	gptModel := fs.String("gpt-model", openai.GPT3Dot5Turbo, fmt.Sprintf("the chat-gpt model used (default %s)", openai.GPT3Dot5Turbo))
+	details := fs.String("details", "", "additional details for label suggestions")
+	excludedLabels := fs.String("excluded-labels", "", "a comma-separated list of labels to exclude from automatic assignment. For example: 'bug,duplicate'")
+	maxPromptTokens := fs.Int("max-prompt-tokens", 0, "the maximum number of tokens in the prompt (default is the context window of the model)")
+	maxCandidates := fs.Int("max-candidate-labels", 0, "if positive, only this many labels most similar to the content are offered to the model")
+	alwaysIncluded := fs.String("always-included-labels", "", "a comma-separated list of labels that are always offered to the model when candidate labels are preselected")
+	embeddingsCache := fs.String("embeddings-cache", defaultEmbeddingsCache, "the path to the file with cached label embeddings")
+	fewShotCount := fs.Int("few-shot-examples", 0, "the number of similar issues labeled by maintainers to show to the model as examples (disabled by default)")
+	fewShotTokens := fs.Int("few-shot-tokens", defaultFewShotTokens, fmt.Sprintf("the maximum number of tokens taken by the examples (default %d)", defaultFewShotTokens))
+	fewShotBy := fs.String("few-shot-similarity", similarityKeyword, fmt.Sprintf("how similar examples are found: %q or %q", similarityKeyword, similarityEmbedding))
+	existingLabels := fs.String("existing-labels", string(existingIgnore), fmt.Sprintf("what to do with items that already have labels: %q, %q, %q or %q", existingIgnore, existingSkip, existingSkipGroup, existingFillGroups))
+	labelGroups := fs.String("label-groups", "", "a comma-separated list of label prefixes that form groups. For example: 'kind/,area/'")
+	configPath := fs.String("config", defaultConfigPath, "the path to the YAML file with labeling rules")
+	mode := fs.String("mode", modeApply, fmt.Sprintf("%q applies the labels, %q lists them in the comment for maintainers to approve", modeApply, modeSuggest))
+	correctionsStore := fs.String("corrections-store", "", fmt.Sprintf("where labels changed by maintainers on labeled items are recorded: %q or %q (disabled by default)", storeFile, storeRepo))
+	correctionsPath := fs.String("corrections-path", defaultCorrectionsPath, "the path to the JSONL file with recorded corrections")
+	correctionsExamples := fs.Int("corrections-examples", 0, "the number of recent corrections shown to the model as mistakes to avoid")
+	detectDuplicates := fs.Bool("detect-duplicates", false, "search for existing issues the issue duplicates")
+	duplicateLabel := fs.String("duplicate-label", defaultDuplicateLabel, "the label applied to duplicates")
+	duplicateConfidence := fs.Float64("duplicate-confidence", defaultDuplicateConfidence, "the minimum confidence of the model to mark the issue as a duplicate")
+	duplicateRerank := fs.Bool("duplicate-rerank", false, "re-rank the found issues by embeddings")
+	requiredInfo := fs.String("required-info", "", "a comma-separated list of information the item must provide. For example: 'version,steps to reproduce,logs'")
+	needsInfoLabel := fs.String("needs-info-label", defaultNeedsInfoLabel, "the label applied to items that lack the required information")
+	priority := fs.Bool("priority", false, "estimate the priority with the rubric from the config file and apply one priority label")
+	detectSecurity := fs.Bool("detect-security", false, "detect vulnerabilities reported publicly and escalate them instead of commenting")
+	securityLabel := fs.String("security-label", defaultSecurityLabel, "the label applied to security reports")
+	securityConfidence := fs.Float64("security-confidence", defaultSecurityConfidence, "the minimum confidence of the model to treat the item as a security report")
+	moderate := fs.Bool("moderate", false, "detect spam and abuse, label it and apply the spam actions")
+	spamLabel := fs.String("spam-label", defaultSpamLabel, "the label applied to spam")
+	spamConfidence := fs.Float64("spam-confidence", defaultSpamConfidence, "the minimum confidence of the model to treat the content as spam")
+	spamActions := fs.String("spam-actions", "", fmt.Sprintf("a comma-separated list of actions taken on spam: %q, %q or %q", spamActionClose, spamActionLock, spamActionMinimize))
+	languageLabel := fs.Bool("language-label", false, "apply a lang/xx label to items not written in English")
+	translate := fs.Bool("translate", false, "add an English translation of items not written in English to the comment")
+	discussionCategory := fs.Bool("discussion-category", false, "move discussions to the category chosen by the model")
+	categoryConfidence := fs.Float64("category-confidence", defaultCategoryConfidence, "the minimum confidence of the model to move a discussion")
+	convertQuestions := fs.Bool("convert-questions", false, "move issues that are questions to discussions")
+	questionConfidence := fs.Float64("question-confidence", defaultQuestionConfidence, "the minimum confidence of the model to move an issue to a discussion")
+	qaCategory := fs.String("qa-category", defaultQACategory, "the discussion category questions are moved to")
+	issueTemplates := fs.String("issue-templates", defaultIssueTemplates, "the directory with issue forms used to parse issue bodies into fields")
+
+	return func(c *config) {
+		c.timeout = *timeout
+		c.details = *details
+		c.excludedLabels = strings.Split(*excludedLabels, ",")
+		c.gptModel = *gptModel
+		c.maxPromptTokens = *maxPromptTokens
+		c.maxCandidates = *maxCandidates
+		c.alwaysIncluded = strings.Split(*alwaysIncluded, ",")
+		c.embeddingsCache = *embeddingsCache
+		c.fewShotCount = *fewShotCount
+		c.fewShotTokens = *fewShotTokens
+		c.fewShotBy = *fewShotBy
+		c.existingLabels = *existingLabels
+		c.labelGroups = strings.Split(*labelGroups, ",")
+		c.configPath = *configPath
+		c.mode = *mode
+		c.correctionsStore = *correctionsStore
+		c.correctionsPath = *correctionsPath
+		c.correctionsExamples = *correctionsExamples
+		c.detectDuplicates = *detectDuplicates
+		c.duplicateLabel = *duplicateLabel
+		c.duplicateConfidence = *duplicateConfidence
+		c.duplicateRerank = *duplicateRerank
+		c.requiredInfo = strings.Split(*requiredInfo, ",")
+		c.needsInfoLabel = *needsInfoLabel
+		c.issueTemplates = *issueTemplates
+		c.priority = *priority
+		c.detectSecurity = *detectSecurity
+		c.securityLabel = *securityLabel
+		c.securityConfidence = *securityConfidence
+		c.moderate = *moderate
+		c.spamLabel = *spamLabel
+		c.spamConfidence = *spamConfidence
+		c.spamActions = strings.Split(*spamActions, ",")
+		c.languageLabel = *languageLabel
+		c.translate = *translate
+		c.discussionCategory = *discussionCategory
+		c.categoryConfidence = *categoryConfidence
+		c.convertQuestions = *convertQuestions
+		c.questionConfidence = *questionConfidence
+		c.qaCategory = *qaCategory
+	}
+}
+
+// commands are subcommands used outside of workflow runs, e.g. locally or on a schedule.
+var commands = map[string]func(args []string) error{
+	"advise-labels": adviseLabelsCommand,
+	"eval":          evalCommand,
+	"export":        exportCommand,
+	"sync-labels":   syncLabelsCommand,
+}
+
+func runCommand(name string, args []string) error {
+	cmd, exist := commands[name]
+	if !exist {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return cmd(args)
+}
+
+// commandEnv fills the tokens and the repository of a subcommand config from the environment.
+// The repository may be overridden, as there is no workflow run to take it from.
+func commandEnv(c *config, repo string) error {
+	if repo == "" {
+		repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok {
+		return fmt.Errorf("invalid repository %q, expected owner/name", repo)
+	}
+
+	c.repoOwner = owner
+	c.repoName = name
+	c.gptToken = os.Getenv("OPENAI_API_KEY")
+
+	c.ghToken = os.Getenv("GITHUB_TOKEN")
+	if c.ghToken == "" {
+		return errors.New("env \"GITHUB_TOKEN\" is required")
+	}
+
+	c.graphQLEndpoint = os.Getenv("GITHUB_GRAPHQL_URL")
+	if c.graphQLEndpoint == "" {
+		c.graphQLEndpoint = defaultGraphQLEndpoint
+	}
+	return nil
+}
+
 func run(cfg config) error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.timeout)*time.Second)
 	defer cancel()
 
-	ghapi := NewGithubClient(cfg.ghToken, cfg.graphQLEndpoint, nil)
+	useModelTokenizer(cfg.gptModel)
+
+	existingPolicy, err := parseExistingLabelsPolicy(cfg.existingLabels)
+	if err != nil {
+		return err
+	}
+	if err := requireGroups(existingPolicy, cfg.labelGroups); err != nil {
+		return err
+	}
+
+	if err := validateSimilarity(cfg.fewShotBy); err != nil {
+		return err
+	}
+
+	if cfg.mode != modeApply && cfg.mode != modeSuggest {
+		return fmt.Errorf("unknown mode %q", cfg.mode)
+	}
+
+	if cfg.correctionsStore != "" && cfg.correctionsStore != storeFile && cfg.correctionsStore != storeRepo {
+		return fmt.Errorf("unknown corrections store %q", cfg.correctionsStore)
+	}
+
+	spamActions, err := parseSpamActions(cfg.spamActions)
+	if err != nil {
+		return err
+	}
 
-	availableLabels, err := ghapi.FetchRepoLabels(ctx, cfg.repoOwner, cfg.repoName)
+	fileCfg, err := loadFileConfig(cfg.configPath)
 	if err != nil {
 		return err
 	}
 
-	availableLabels = filterLabels(availableLabels, cfg.excludedLabels)
+	if err := fileCfg.Routing.validate(); err != nil {
+		return err
+	}
+
+	if err := fileCfg.Milestones.validate(); err != nil {
+		return err
+	}
 
 	ef, err := os.Open(cfg.eventPath)
 	if err != nil {
@@ -102,39 +319,340 @@ func run(cfg config) error {
 		return fmt.Errorf("failed to parse event: %w", err)
 	}
 
-	assistant := newLabelingAssistant(cfg.gptToken, cfg.gptModel, nil)
+	ghapi := NewGithubClient(cfg.ghToken, cfg.graphQLEndpoint, nil)
+
+	if cfg.correctionsStore != "" && (payload.action == "labeled" || payload.action == "unlabeled") {
+		return recordCorrection(ctx, cfg, ghapi, payload)
+	}
+
+	if cfg.moderate {
+		moderated, err := moderate(ctx, cfg, ghapi, fileCfg, payload, spamActions)
+		if err != nil {
+			return err
+		}
+		if moderated {
+			return nil
+		}
+	}
+
+	// commands of maintainers are not filtered by the author of the item
+	if payload.comment == nil {
+		if reason := fileCfg.skipReason(payload.author); reason != "" {
+			log.Printf("Skipping the %s: %s.", objectName(cfg.eventName), reason)
+			return nil
+		}
+	}
 
-	labels, err := json.Marshal(availableLabels)
+	cfg.details, cfg.excludedLabels = fileCfg.applyAuthorRules(payload.author, cfg.details, cfg.excludedLabels)
+
+	var dryRun bool
+	if payload.comment != nil {
+		if payload.action == "edited" {
+			return handleSuggestionEdit(ctx, cfg, ghapi, payload)
+		}
+
+		cmd, accepted, err := acceptSlashCommand(ctx, cfg, ghapi, payload)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			// reactions approve suggestions, which are only made in the suggest mode
+			if cfg.mode != modeSuggest {
+				return nil
+			}
+			return applyApprovedSuggestion(ctx, cfg, ghapi, payload)
+		}
+
+		if cmd.details != "" {
+			cfg.details = strings.TrimSpace(cfg.details + "\n" + cmd.details)
+		}
+		dryRun = cmd.dryRun
+
+		// the maintainer explicitly asked to label the item again
+		if existingPolicy == existingSkip || existingPolicy == existingSkipGroup {
+			existingPolicy = existingIgnore
+		}
+	}
+
+	if objectName(cfg.eventName) == "issue" {
+		forms, err := loadIssueForms(cfg.issueTemplates)
+		if err != nil {
+			return err
+		}
+		payload.fields = parseIssueForm(payload.body, forms)
+	}
+
+	// the comment left by the previous run, if the item is being labeled again after an edit
+	var previous *botComment
+	if payload.action == "edited" && payload.comment == nil {
+		if !payload.contentChanged() {
+			log.Println("Neither the title nor the body has changed.")
+			return nil
+		}
+
+		previous, err = ghapi.FetchBotComment(ctx, payload.nodeID)
+		if err != nil {
+			return err
+		}
+
+		if previous != nil && previous.Marker.Hash == payloadHash(payload) {
+			log.Println("The title and the body have not changed meaningfully.")
+			return nil
+		}
+	}
+
+	// the labels applied by the previous run do not count as labeled by a human
+	existingLabels := payload.labels
+	if previous != nil {
+		existingLabels = slices.DeleteFunc(slices.Clone(existingLabels), func(l string) bool {
+			return slices.Contains(previous.Marker.Labels, l)
+		})
+	}
+
+	if existingPolicy == existingSkip && len(existingLabels) > 0 {
+		log.Printf("The %s is already labeled: %s", cfg.eventName, strings.Join(existingLabels, ", "))
+		return nil
+	}
+
+	repoLabels, err := ghapi.FetchRepoLabels(ctx, cfg.repoOwner, cfg.repoName)
 	if err != nil {
-		return fmt.Errorf("failed to marshal labels: %w", err)
+		return err
 	}
 
-	gptResponse, err := assistant.GetLabels(ctx, getLabelsRequest{
-		labels:  string(labels),
-		payload: payload.String(),
-		details: cfg.details,
-	})
+	candidateLabels, availableLabels, skip := scopeLabels(
+		existingPolicy, cfg.labelGroups, existingLabels, filterLabels(repoLabels, cfg.excludedLabels),
+	)
+	if skip {
+		log.Printf("The %s is already labeled: %s", cfg.eventName, strings.Join(existingLabels, ", "))
+		return nil
+	}
+
+	var vetoed []string
+	// discussions have no timeline to find removed labels in
+	if objectName(cfg.eventName) != "discussion" {
+		events, err := ghapi.FetchLabelEvents(ctx, payload.nodeID)
+		if err != nil {
+			return err
+		}
+		vetoed = vetoedLabels(events, resolveBotLogin(ctx, ghapi))
+		if len(vetoed) > 0 {
+			log.Printf("Labels removed by maintainers are not applied again: %s", strings.Join(vetoed, ", "))
+		}
+	}
+
+	availableLabels = filterLabels(availableLabels, vetoed)
+	candidateLabels = filterLabels(candidateLabels, vetoed)
+
+	lb := newLabeler(cfg, ghapi)
+
+	if cfg.detectSecurity && securityEscalated(cfg, payload, previous) {
+		log.Println("The security report is already escalated.")
+		return nil
+	}
+
+	if cfg.detectSecurity {
+		verdict, err := lb.assistant.DetectSecurityReport(ctx, payload.String())
+		if err != nil {
+			return err
+		}
+		if verdict.detected(cfg.securityConfidence) {
+			return escalateSecurityReport(ctx, cfg, ghapi, fileCfg.SecurityComment, payload, verdict, repoLabels, dryRun)
+		}
+	}
+
+	// only new issues are converted, not the ones commented on or edited
+	if cfg.convertQuestions && objectName(cfg.eventName) == "issue" && payload.comment == nil && payload.action != "edited" {
+		converted, err := convertQuestion(ctx, cfg, ghapi, lb.assistant, payload, dryRun)
+		if err != nil {
+			log.Printf("Failed to move the question to a discussion: %v", err)
+		}
+		if converted {
+			return nil
+		}
+	}
+
+	var (
+		priorityCriteria []priorityCriterion
+		priorityLevels   []priorityLevel
+	)
+	topicalLabels := candidateLabels
+	if cfg.priority {
+		priorityCriteria, priorityLevels = fileCfg.Priority.rubric(availableLabels)
+		// the priority is chosen by the rubric rather than together with the topical labels
+		topicalLabels = slices.DeleteFunc(slices.Clone(topicalLabels), func(l Label) bool {
+			return isPriorityLabel(l.Name, priorityLevels)
+		})
+	}
+	if cfg.languageLabel {
+		// the language is detected by a separate step
+		topicalLabels = slices.DeleteFunc(slices.Clone(topicalLabels), func(l Label) bool {
+			return strings.HasPrefix(strings.ToLower(l.Name), languagePrefix)
+		})
+	}
+
+	gptResponse, err := lb.classify(ctx, topicalLabels, payload)
 
 	if errors.Is(err, ErrEmptyMessage) {
+		// the optional stages below may still choose labels
 		log.Println("ChatGPT returned an empty message.")
-		return nil
 	} else if err != nil {
 		return err
 	}
 
-	if err := ghapi.ReplaceLabels(ctx, payload.nodeID, gptResponse.labelIDs()); err != nil {
-		return err
+	gptResponse = gptResponse.without(vetoed)
+
+	for _, l := range fieldLabels(payload.fields, fileCfg.FieldLabels) {
+		explanation := fmt.Sprintf("%q was selected for %q in the issue form.", l.Value, l.Field)
+		gptResponse = gptResponse.with(availableLabels, l.Label, explanation)
 	}
 
-	artifactName := cfg.eventName
-	if artifactName == "issues" {
-		artifactName = "issue"
+	// additional parts of the comment produced by the optional stages
+	var sections []string
+
+	// only issues can be searched for duplicates
+	if cfg.detectDuplicates && objectName(cfg.eventName) == "issue" {
+		section, err := lb.findDuplicate(ctx, payload)
+		if err != nil {
+			// the duplicate search is an extra, the labels are applied without it
+			log.Printf("Failed to search for duplicates: %v", err)
+		} else if section != "" {
+			gptResponse = gptResponse.with(availableLabels, cfg.duplicateLabel, "The issue duplicates an existing one.")
+			if gptResponse.has(cfg.duplicateLabel) {
+				sections = append(sections, section)
+			}
+		}
 	}
 
-	body := strconv.Quote(createComment(artifactName, cfg.repoOwner, cfg.repoName, gptResponse))
+	if cfg.languageLabel || cfg.translate {
+		verdict, err := lb.assistant.DetectLanguage(ctx, payload.String(), cfg.translate)
+		if err != nil {
+			// the language is an extra, the labels are applied without it
+			log.Printf("Failed to detect the language: %v", err)
+		} else if !verdict.english() {
+			log.Printf("The %s is written in %q.", objectName(cfg.eventName), verdict.Language)
+			if cfg.translate && verdict.Body != "" {
+				sections = append(sections, formatTranslation(verdict))
+			}
+			if cfg.languageLabel {
+				explanation := fmt.Sprintf("The %s is written in %q.", objectName(cfg.eventName), verdict.Language)
+				gptResponse = gptResponse.with(availableLabels, verdict.label(), explanation)
+			}
+		}
+	}
+
+	if cfg.priority {
+		if level := fileCfg.Priority.existing(existingLabels, repoLabels); level != "" {
+			// the priority set by a maintainer is not overridden
+			log.Printf("The priority %q is already set.", level)
+		} else if len(priorityLevels) == 0 {
+			log.Println("No priority labels are available.")
+		} else if verdict, err := lb.assistant.EstimatePriority(ctx, payload.String(), priorityCriteria, priorityLevels); err != nil {
+			// the priority is an extra, the labels are applied without it
+			log.Printf("Failed to estimate the priority: %v", err)
+		} else if !isPriorityLabel(verdict.Label, priorityLevels) {
+			log.Printf("The model chose an unknown priority %q.", verdict.Label)
+		} else {
+			gptResponse = gptResponse.with(availableLabels, verdict.Label, verdict.Explanation)
+			if gptResponse.has(verdict.Label) {
+				sections = append(sections, formatPriority(verdict))
+			}
+		}
+	}
+
+	if fields := requiredFields(cfg.requiredInfo); len(fields) > 0 {
+		verdict, err := lb.assistant.CheckCompleteness(ctx, payload.String(), fields)
+		if err != nil {
+			// the completeness check is an extra, the labels are applied without it
+			log.Printf("Failed to check the required information: %v", err)
+		} else if !verdict.complete() {
+			sections = append(sections, formatQuestions(verdict))
+			explanation := fmt.Sprintf("Missing information: %s.", strings.Join(verdict.Missing, ", "))
+			gptResponse = gptResponse.with(availableLabels, cfg.needsInfoLabel, explanation)
+		} else {
+			gptResponse = gptResponse.without([]string{cfg.needsInfoLabel})
+
+			// the author has provided the information requested before
+			if payload.action == "edited" && slices.Contains(payload.labels, cfg.needsInfoLabel) && !dryRun && cfg.mode != modeSuggest {
+				if err := removeStaleLabels(ctx, ghapi, payload, repoLabels, []string{cfg.needsInfoLabel}, nil); err != nil {
+					return err
+				}
+				payload.labels = slices.DeleteFunc(payload.labels, func(l string) bool { return l == cfg.needsInfoLabel })
+				log.Printf("The required information is provided, the %q label is removed.", cfg.needsInfoLabel)
+			}
+		}
+	}
+
+	// the category is chosen regardless of the labels
+	if cfg.discussionCategory && objectName(cfg.eventName) == "discussion" && !dryRun && cfg.mode != modeSuggest {
+		section, err := moveDiscussion(ctx, cfg, ghapi, lb.assistant, payload)
+		if err != nil {
+			log.Printf("Failed to move the discussion: %v", err)
+		} else if section != "" {
+			sections = append(sections, section)
+		}
+	}
+
+	if len(gptResponse.Labels) == 0 {
+		log.Println("No labels to apply.")
+		return nil
+	}
+
+	chosen := gptResponse.labelNames()
+	suggest := cfg.mode == modeSuggest
+
+	if dryRun {
+		log.Println("Dry run, the labels are not applied.")
+	} else if suggest {
+		log.Println("The labels are suggested to maintainers.")
+	} else {
+		if err := ghapi.ReplaceLabels(ctx, payload.nodeID, gptResponse.labelIDs()); err != nil {
+			return err
+		}
+
+		if previous != nil && !previous.Marker.Suggest {
+			if err := removeStaleLabels(ctx, ghapi, payload, repoLabels, previous.Marker.Labels, chosen); err != nil {
+				return err
+			}
+		}
+
+		// the labels are applied already, so the comment is posted even if nobody is assigned
+		if err := assignPeople(ctx, cfg, ghapi, fileCfg.Routing, payload, chosen); err != nil {
+			log.Printf("Failed to assign people: %v", err)
+		}
+
+		if err := addToProjects(ctx, cfg, ghapi, fileCfg.Projects, payload, chosen); err != nil {
+			log.Printf("Failed to add the %s to projects: %v", objectName(cfg.eventName), err)
+		}
+
+		section, err := setMilestone(ctx, cfg, ghapi, lb.assistant, fileCfg.Milestones, payload, chosen)
+		if err != nil {
+			log.Printf("Failed to set the milestone: %v", err)
+		} else if section != "" {
+			sections = append(sections, section)
+		}
+	}
+
+	artifactName := objectName(cfg.eventName)
+
+	comment := createComment(artifactName, cfg.repoOwner, cfg.repoName, gptResponse, suggest, sections...)
+	if dryRun {
+		comment = dryRunNote + comment
+	} else {
+		comment += "\n" + commentMarker{Hash: payloadHash(payload), Labels: chosen, Suggest: suggest}.String()
+	}
+
+	if previous != nil {
+		updateCommentFn := ghapi.UpdateComment
+		if artifactName == "discussion" {
+			updateCommentFn = ghapi.UpdateDiscussionComment
+		}
+		return updateCommentFn(ctx, previous.ID, comment)
+	}
+
+	body := strconv.Quote(comment)
 
 	var addCommentFn = ghapi.AddComment
-	if cfg.eventName == "discussion" {
+	if artifactName == "discussion" {
 		addCommentFn = ghapi.AddDiscussionComment
 	}
 
@@ -145,6 +663,75 @@ func run(cfg config) error {
 	return nil
 }
 
+// removeStaleLabels removes the labels applied by the previous run that are no longer chosen.
+func removeStaleLabels(
+	ctx context.Context, ghapi *GitHubGraphQLClient, p payload, repoLabels []Label, previous, chosen []string,
+) error {
+	var stale []string
+	for _, l := range repoLabels {
+		if slices.Contains(previous, l.Name) && !slices.Contains(chosen, l.Name) && slices.Contains(p.labels, l.Name) {
+			stale = append(stale, l.ID)
+		}
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+	return ghapi.RemoveLabels(ctx, p.nodeID, stale)
+}
+
+// triagePermissions are the repository permissions that allow to use the slash command.
+var triagePermissions = []string{"ADMIN", "MAINTAIN", "WRITE", "TRIAGE"}
+
+// acceptSlashCommand parses the command in the comment and checks that its author may triage.
+// Accepted commands are acknowledged with a reaction. It reports false if the comment must be ignored.
+func acceptSlashCommand(
+	ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, p payload,
+) (slashCommand, bool, error) {
+	if p.action != "created" {
+		return slashCommand{}, false, nil
+	}
+
+	cmd, ok, parseErr := parseSlashCommand(p.comment.body)
+	if !ok {
+		log.Println("The comment is not a command.")
+		return slashCommand{}, false, nil
+	}
+
+	allowed, err := hasTriageAccess(ctx, cfg, ghapi, p.comment.author)
+	if err != nil {
+		return slashCommand{}, false, err
+	}
+
+	if !allowed {
+		log.Printf("User %q is not allowed to run %s.", p.comment.author, slashCommandName)
+		return slashCommand{}, false, nil
+	}
+
+	if parseErr != nil {
+		log.Println(parseErr)
+		return slashCommand{}, false, ghapi.AddReaction(ctx, p.comment.nodeID, "CONFUSED")
+	}
+
+	if err := ghapi.AddReaction(ctx, p.comment.nodeID, "EYES"); err != nil {
+		return slashCommand{}, false, err
+	}
+
+	return cmd, true, nil
+}
+
+func hasTriageAccess(ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, login string) (bool, error) {
+	if login == "" {
+		return false, nil
+	}
+
+	permission, err := ghapi.FetchPermission(ctx, cfg.repoOwner, cfg.repoName, login)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(triagePermissions, permission), nil
+}
+
 func filterLabels(labels []Label, excluded []string) []Label {
 	if len(excluded) == 0 {
 		return labels
@@ -164,17 +751,69 @@ func filterLabels(labels []Label, excluded []string) []Label {
 	return filtered
 }
 
-func createComment(artifactName string, repoOwner string, repoName string, r getLabelsResponse) string {
+// marshalLabels encodes the labels for the prompt.
+// If they do not fit into the budget, the descriptions are dropped.
+// If they still do not fit, it fails rather than overflow the context window.
+func marshalLabels(labels []Label, budget int) (string, error) {
+	// colors mean nothing to the model
+	full := make([]Label, 0, len(labels))
+	for _, l := range labels {
+		full = append(full, Label{ID: l.ID, Name: l.Name, Description: l.Description})
+	}
+
+	b, err := json.Marshal(full)
+	if err != nil {
+		return "", err
+	}
+
+	if countTokens(string(b)) <= budget {
+		return string(b), nil
+	}
+
+	log.Println("The labels do not fit into the prompt budget, their descriptions are omitted.")
+
+	short := make([]Label, 0, len(labels))
+	for _, l := range labels {
+		short = append(short, Label{ID: l.ID, Name: l.Name})
+	}
+
+	b, err = json.Marshal(short)
+	if err != nil {
+		return "", err
+	}
+
+	if n := countTokens(string(b)); n > budget {
+		return "", fmt.Errorf(
+			"the labels take %d tokens without descriptions, more than the budget of %d tokens: "+
+				"exclude labels or preselect them with -max-candidate-labels", n, budget)
+	}
+	return string(b), nil
+}
+
+const dryRunNote = "**Dry run:** the labels below were suggested but not applied.\n\n"
+
+func createComment(
+	artifactName string, repoOwner string, repoName string, r getLabelsResponse, suggest bool, sections ...string,
+) string {
 	body := `**Automated Label Assignment:**
 
 Hello there! 👋 This is an automated message from the ChatGPT Auto Labeler Action.
 
-The ChatGPT Auto Labeler has analyzed the title and content of this %s and assigned the following labels:
 `
+	if suggest {
+		body += "The ChatGPT Auto Labeler has analyzed the title and content of this %s and suggests the following labels. "
+		body += "Maintainers can tick the boxes to apply them or react with 👍 to apply all of them:\n"
+	} else {
+		body += "The ChatGPT Auto Labeler has analyzed the title and content of this %s and assigned the following labels:\n"
+	}
 	body = fmt.Sprintf(body, artifactName)
 
 	for _, l := range r.Labels {
-		body += fmt.Sprintf("- **%s**: %s\n", l.Name, l.Explanation)
+		if suggest {
+			body += fmt.Sprintf("- [ ] **%s**: %s\n", l.Name, l.Explanation)
+		} else {
+			body += fmt.Sprintf("- **%s**: %s\n", l.Name, l.Explanation)
+		}
 	}
 
 	body += "\n\n" + r.Explanation
@@ -188,6 +827,10 @@ The ChatGPT Auto Labeler has analyzed the title and content of this %s and assig
 		body += fmt.Sprintf("- [%s](%s)\n", l.Name, labelURL)
 	}
 
+	for _, section := range sections {
+		body += "\n" + section
+	}
+
 	footer := "\n\n*Note: This message is generated automatically, and the labels were assigned based on the analysis of the %s's content.*"
 
 	footer = fmt.Sprintf(footer, artifactName)
@@ -195,12 +838,62 @@ The ChatGPT Auto Labeler has analyzed the title and content of this %s and assig
 }
 
 type payload struct {
+	action  string
+	nodeID  string
+	number  int
+	url     string
+	title   string
+	body    string
+	labels  []string
+	comment *comment
+	author  author
+	// sender is the user who triggered the event
+	sender string
+	// changes are the names of the edited fields
+	changes []string
+	// label is the label added or removed by the event
+	label string
+	// category is the name of the discussion category
+	category string
+	// milestone is the title of the milestone of the item
+	milestone string
+	// assignees are the logins of the users assigned to the item
+	assignees []string
+	// reviewers are the logins of the users and the slugs of the teams requested to review the pull request
+	reviewers []string
+	// fields are the answers of the issue form the body was created from
+	fields []formField
+	// pullRequest is set for pull requests delivered as issues, e.g. by issue_comment
+	pullRequest bool
+}
+
+// kind returns the kind of the item, unlike objectName it tells pull requests from issues on issue_comment.
+func (d payload) kind(eventName string) string {
+	if d.pullRequest {
+		return "pull_request"
+	}
+	return objectName(eventName)
+}
+
+func (d payload) contentChanged() bool {
+	return slices.Contains(d.changes, "title") || slices.Contains(d.changes, "body")
+}
+
+// comment is the comment that triggered the event.
+type comment struct {
 	nodeID string
-	title  string
 	body   string
+	author string
+	// association is the relation of the author to the repository
+	association string
+	// previousBody is the body before the edit
+	previousBody string
 }
 
 func (d payload) String() string {
+	if len(d.fields) > 0 {
+		return fmt.Sprintf("Title: %s\nBody:\n%s", d.title, formatFields(d.fields))
+	}
 	return fmt.Sprintf("Title: %s\nBody: %s", d.title, d.body)
 }
 
@@ -210,10 +903,7 @@ func payloadFromEvent(eventName string, r io.Reader) (payload, error) {
 		return payload{}, fmt.Errorf("failed to decode %s event: %w", eventName, err)
 	}
 
-	objName := eventName
-	if eventName == "issues" {
-		objName = "issue"
-	}
+	objName := objectName(eventName)
 
 	obj, exist := event[objName]
 	if !exist {
@@ -222,14 +912,110 @@ func payloadFromEvent(eventName string, r io.Reader) (payload, error) {
 
 	if m, ok := obj.(map[string]any); ok {
 		p := payload{}
+		p.action, _ = event["action"].(string)
+		p.comment = commentFromEvent(event)
+		p.author = authorFromObject(m)
+		if sender, ok := event["sender"].(map[string]any); ok {
+			p.sender, _ = sender["login"].(string)
+		}
+		if changes, ok := event["changes"].(map[string]any); ok {
+			for field := range changes {
+				p.changes = append(p.changes, field)
+			}
+			slices.Sort(p.changes)
+		}
+		if label, ok := event["label"].(map[string]any); ok {
+			p.label, _ = label["name"].(string)
+		}
 		p.nodeID = m["node_id"].(string)
+		p.url, _ = m["html_url"].(string)
+		if number, ok := m["number"].(float64); ok {
+			p.number = int(number)
+		}
 		p.title = m["title"].(string)
 		if body, ok := m["body"].(string); ok {
 			p.body = body
 		}
+		if category, ok := m["category"].(map[string]any); ok {
+			p.category, _ = category["name"].(string)
+		}
+		if milestone, ok := m["milestone"].(map[string]any); ok {
+			p.milestone, _ = milestone["title"].(string)
+		}
+		_, p.pullRequest = m["pull_request"]
+		p.assignees = namesFromList(m["assignees"], "login")
+		p.reviewers = append(namesFromList(m["requested_reviewers"], "login"), namesFromList(m["requested_teams"], "slug")...)
+		if labels, ok := m["labels"].([]any); ok {
+			for _, l := range labels {
+				if label, ok := l.(map[string]any); ok {
+					if name, ok := label["name"].(string); ok {
+						p.labels = append(p.labels, name)
+					}
+				}
+			}
+		}
 
 		return p, nil
 	}
 
 	return payload{}, errors.New("invalid event")
 }
+
+// objectName returns the name of the event field with the labeled item.
+func objectName(eventName string) string {
+	switch eventName {
+	case "issues", "issue_comment":
+		return "issue"
+	case "discussion_comment":
+		return "discussion"
+	default:
+		return eventName
+	}
+}
+
+// namesFromList returns the key of every object in the list, e.g. the logins of the users.
+func namesFromList(list any, key string) []string {
+	objects, _ := list.([]any)
+
+	var names []string
+	for _, o := range objects {
+		if m, ok := o.(map[string]any); ok {
+			if name, ok := m[key].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func commentFromEvent(event map[string]any) *comment {
+	m, ok := event["comment"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	c := &comment{}
+	c.nodeID, _ = m["node_id"].(string)
+	c.body, _ = m["body"].(string)
+	c.association, _ = m["author_association"].(string)
+	if changes, ok := event["changes"].(map[string]any); ok {
+		if body, ok := changes["body"].(map[string]any); ok {
+			c.previousBody, _ = body["from"].(string)
+		}
+	}
+	if user, ok := m["user"].(map[string]any); ok {
+		c.author, _ = user["login"].(string)
+	}
+	return c
+}
+
+func authorFromObject(obj map[string]any) author {
+	var a author
+	a.association, _ = obj["author_association"].(string)
+	if user, ok := obj["user"].(map[string]any); ok {
+		a.login, _ = user["login"].(string)
+		userType, _ := user["type"].(string)
+		a.isBot = userType == "Bot"
+	}
+	return a
+}
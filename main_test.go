@@ -33,7 +33,7 @@ func TestPayloadFromEvent(t *testing.T) {
 					}
 				}`,
 			},
-			expected: payload{title: "Some title", body: "Some body", nodeID: "D_kwDOKgkPac4AWfor"},
+			expected: payload{action: "created", title: "Some title", body: "Some body", nodeID: "D_kwDOKgkPac4AWfor"},
 		},
 		{
 			name: "issue opened",
@@ -48,7 +48,7 @@ func TestPayloadFromEvent(t *testing.T) {
 					}
 				}`,
 			},
-			expected: payload{title: "Some title", body: "Some body", nodeID: "D_kwDOKgkPac4AWfor"},
+			expected: payload{action: "opened", title: "Some title", body: "Some body", nodeID: "D_kwDOKgkPac4AWfor"},
 		},
 		{
 			name: "pull_request opened",
@@ -63,7 +63,23 @@ func TestPayloadFromEvent(t *testing.T) {
 					}
 				}`,
 			},
-			expected: payload{title: "Some title", body: "Some body", nodeID: "D_kwDOKgkPac4AWfor"},
+			expected: payload{action: "opened", title: "Some title", body: "Some body", nodeID: "D_kwDOKgkPac4AWfor"},
+		},
+		{
+			name: "issue opened with labels",
+			args: args{
+				eventName: "issues",
+				event: `{
+					"action": "opened",
+					"issue": {
+						"body": "Some body",
+						"title": "Some title",
+						"node_id": "D_kwDOKgkPac4AWfor",
+						"labels": [{"id": 1, "name": "bug"}, {"id": 2, "name": "area/cli"}]
+					}
+				}`,
+			},
+			expected: payload{action: "opened", title: "Some title", body: "Some body", nodeID: "D_kwDOKgkPac4AWfor", labels: []string{"bug", "area/cli"}},
 		},
 		{
 			name: "pull_request opened with empty body",
@@ -77,7 +93,160 @@ func TestPayloadFromEvent(t *testing.T) {
 					}
 				}`,
 			},
-			expected: payload{title: "Some title", nodeID: "D_kwDOKgkPac4AWfor"},
+			expected: payload{action: "opened", title: "Some title", nodeID: "D_kwDOKgkPac4AWfor"},
+		},
+		{
+			name: "pull_request opened with assignees and reviewers",
+			args: args{
+				eventName: "pull_request",
+				event: `{
+					"action": "opened",
+					"pull_request": {
+						"title": "Some title",
+						"node_id": "D_kwDOKgkPac4AWfor",
+						"assignees": [{"login": "alice"}],
+						"requested_reviewers": [{"login": "bob"}],
+						"requested_teams": [{"slug": "docs"}]
+					}
+				}`,
+			},
+			expected: payload{
+				action:    "opened",
+				title:     "Some title",
+				nodeID:    "D_kwDOKgkPac4AWfor",
+				assignees: []string{"alice"},
+				reviewers: []string{"bob", "docs"},
+			},
+		},
+		{
+			name: "issue comment created",
+			args: args{
+				eventName: "issue_comment",
+				event: `{
+					"action": "created",
+					"issue": {
+						"body": "Some body",
+						"title": "Some title",
+						"node_id": "I_kwDOKgkPac4AWfor"
+					},
+					"comment": {
+						"body": "/auto-label dry-run",
+						"node_id": "IC_kwDOKgkPac4AWfor",
+						"user": {"login": "maintainer"}
+					}
+				}`,
+			},
+			expected: payload{
+				action: "created", title: "Some title", body: "Some body", nodeID: "I_kwDOKgkPac4AWfor",
+				comment: &comment{nodeID: "IC_kwDOKgkPac4AWfor", body: "/auto-label dry-run", author: "maintainer"},
+			},
+		},
+		{
+			name: "pull request comment created",
+			args: args{
+				eventName: "issue_comment",
+				event: `{
+					"action": "created",
+					"issue": {
+						"body": "Some body",
+						"title": "Some title",
+						"node_id": "PR_kwDOKgkPac4AWfor",
+						"pull_request": {"url": "https://api.github.com/repos/owner/repo/pulls/1"}
+					},
+					"comment": {
+						"body": "/auto-label",
+						"node_id": "IC_kwDOKgkPac4AWfor",
+						"user": {"login": "maintainer"}
+					}
+				}`,
+			},
+			expected: payload{
+				action: "created", title: "Some title", body: "Some body", nodeID: "PR_kwDOKgkPac4AWfor", pullRequest: true,
+				comment: &comment{nodeID: "IC_kwDOKgkPac4AWfor", body: "/auto-label", author: "maintainer"},
+			},
+		},
+		{
+			name: "discussion comment created",
+			args: args{
+				eventName: "discussion_comment",
+				event: `{
+					"action": "created",
+					"discussion": {
+						"body": "Some body",
+						"title": "Some title",
+						"node_id": "D_kwDOKgkPac4AWfor"
+					},
+					"comment": {
+						"body": "/auto-label",
+						"node_id": "DC_kwDOKgkPac4AWfor",
+						"user": {"login": "maintainer"}
+					}
+				}`,
+			},
+			expected: payload{
+				action: "created", title: "Some title", body: "Some body", nodeID: "D_kwDOKgkPac4AWfor",
+				comment: &comment{nodeID: "DC_kwDOKgkPac4AWfor", body: "/auto-label", author: "maintainer"},
+			},
+		},
+		{
+			name: "issue edited",
+			args: args{
+				eventName: "issues",
+				event: `{
+					"action": "edited",
+					"changes": {"body": {"from": "Old body"}, "title": {"from": "Old title"}},
+					"issue": {
+						"body": "Some body",
+						"title": "Some title",
+						"node_id": "I_kwDOKgkPac4AWfor"
+					}
+				}`,
+			},
+			expected: payload{
+				action: "edited", title: "Some title", body: "Some body", nodeID: "I_kwDOKgkPac4AWfor",
+				changes: []string{"body", "title"},
+			},
+		},
+		{
+			name: "issue opened by a bot",
+			args: args{
+				eventName: "issues",
+				event: `{
+					"action": "opened",
+					"issue": {
+						"body": "Some body",
+						"title": "Some title",
+						"node_id": "I_kwDOKgkPac4AWfor",
+						"author_association": "NONE",
+						"user": {"login": "dependabot[bot]", "type": "Bot"}
+					}
+				}`,
+			},
+			expected: payload{
+				action: "opened", title: "Some title", body: "Some body", nodeID: "I_kwDOKgkPac4AWfor",
+				author: author{login: "dependabot[bot]", association: "NONE", isBot: true},
+			},
+		},
+		{
+			name: "issue unlabeled",
+			args: args{
+				eventName: "issues",
+				event: `{
+					"action": "unlabeled",
+					"label": {"name": "bug"},
+					"sender": {"login": "maintainer"},
+					"issue": {
+						"body": "Some body",
+						"title": "Some title",
+						"number": 42,
+						"node_id": "I_kwDOKgkPac4AWfor"
+					}
+				}`,
+			},
+			expected: payload{
+				action: "unlabeled", title: "Some title", body: "Some body", nodeID: "I_kwDOKgkPac4AWfor",
+				number: 42, label: "bug", sender: "maintainer",
+			},
 		},
 	}
 
@@ -147,3 +316,39 @@ func TestFilterLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalLabels(t *testing.T) {
+	labels := []Label{
+		{ID: "1", Name: "bug", Description: "Something isn't working", Color: "d73a4a"},
+	}
+
+	t.Run("fits", func(t *testing.T) {
+		s, err := marshalLabels(labels, 100)
+		require.NoError(t, err)
+		assert.Equal(t, `[{"name":"bug","description":"Something isn't working","id":"1"}]`, s)
+	})
+
+	t.Run("without descriptions", func(t *testing.T) {
+		s, err := marshalLabels(labels, 30)
+		require.NoError(t, err)
+		assert.Equal(t, `[{"name":"bug","description":"","id":"1"}]`, s)
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		_, err := marshalLabels(labels, 10)
+		require.Error(t, err)
+	})
+}
+
+func TestPayloadKind(t *testing.T) {
+	assert.Equal(t, "issue", payload{}.kind("issue_comment"))
+	assert.Equal(t, "pull_request", payload{pullRequest: true}.kind("issue_comment"))
+	assert.Equal(t, "discussion", payload{}.kind("discussion_comment"))
+}
+
+func TestPayloadContentChanged(t *testing.T) {
+	assert.True(t, payload{changes: []string{"body"}}.contentChanged())
+	assert.True(t, payload{changes: []string{"base", "title"}}.contentChanged())
+	assert.False(t, payload{changes: []string{"base"}}.contentChanged())
+	assert.False(t, payload{}.contentChanged())
+}
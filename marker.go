@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// commentMarker is hidden in the bot comment to remember what was labeled,
+// so that edits that do not change the content do not trigger labeling again.
+type commentMarker struct {
+	Hash   string   `json:"hash"`
+	Labels []string `json:"labels"`
+	// Suggest is set if the labels were suggested rather than applied.
+	Suggest bool `json:"suggest,omitempty"`
+	// Approved is set once a maintainer approved all suggested labels.
+	Approved bool `json:"approved,omitempty"`
+	// Security is set once the item was escalated as a security report.
+	Security bool `json:"security,omitempty"`
+}
+
+var markerRegexp = regexp.MustCompile(`<!-- auto-label (\{.*?\}) -->`)
+
+func (m commentMarker) String() string {
+	b, _ := json.Marshal(m)
+	return fmt.Sprintf("<!-- auto-label %s -->", b)
+}
+
+func parseCommentMarker(body string) (commentMarker, bool) {
+	match := markerRegexp.FindStringSubmatch(body)
+	if match == nil {
+		return commentMarker{}, false
+	}
+
+	var m commentMarker
+	if err := json.Unmarshal([]byte(match[1]), &m); err != nil {
+		return commentMarker{}, false
+	}
+	return m, true
+}
+
+// payloadHash hashes the title and the body ignoring differences in whitespace.
+func payloadHash(p payload) string {
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	sum := sha256.Sum256([]byte(normalize(p.title) + "\n" + normalize(p.body)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentMarker(t *testing.T) {
+	marker := commentMarker{Hash: "abc", Labels: []string{"bug", "area/cli"}}
+
+	body := "Some comment\n" + marker.String() + "\n"
+	assert.Contains(t, body, `<!-- auto-label {"hash":"abc","labels":["bug","area/cli"]} -->`)
+
+	parsed, ok := parseCommentMarker(body)
+	assert.True(t, ok)
+	assert.Equal(t, marker, parsed)
+
+	_, ok = parseCommentMarker("Some comment")
+	assert.False(t, ok)
+
+	_, ok = parseCommentMarker("<!-- auto-label {invalid} -->")
+	assert.False(t, ok)
+}
+
+func TestPayloadHash(t *testing.T) {
+	p := payload{title: "Crash on start", body: "Steps:\n1. Run"}
+
+	assert.Equal(t, payloadHash(p), payloadHash(payload{title: " Crash  on start", body: "Steps:\r\n1. Run\n\n"}))
+	assert.NotEqual(t, payloadHash(p), payloadHash(payload{title: "Crash on start", body: "Steps:\n1. Build"}))
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	milestoneStrategyRules = "rules"
+	milestoneStrategyModel = "model"
+
+	// nextMilestone refers to the open milestone with the earliest due date.
+	nextMilestone = "next"
+)
+
+// milestoneConfig sets the milestone of labeled issues and pull requests.
+type milestoneConfig struct {
+	// Strategy is "rules" (default) or "model", which lets the model choose among the open milestones.
+	Strategy string          `yaml:"strategy"`
+	Rules    []milestoneRule `yaml:"rules"`
+}
+
+// milestoneRule sets the milestone of items with any of the labels.
+type milestoneRule struct {
+	Labels []string `yaml:"labels"`
+	// Milestone is the title of the milestone or "next".
+	Milestone string `yaml:"milestone"`
+}
+
+func (c milestoneConfig) enabled() bool {
+	return c.Strategy == milestoneStrategyModel || len(c.Rules) > 0
+}
+
+func (c milestoneConfig) validate() error {
+	if c.Strategy != "" && c.Strategy != milestoneStrategyRules && c.Strategy != milestoneStrategyModel {
+		return fmt.Errorf("unknown milestone strategy %q", c.Strategy)
+	}
+	return nil
+}
+
+type milestone struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueOn       *time.Time `json:"dueOn"`
+}
+
+// findMilestone returns the milestone with the title or, for "next", the one due first.
+func findMilestone(milestones []milestone, title string) (milestone, bool) {
+	if strings.EqualFold(title, nextMilestone) {
+		var next *milestone
+		for i, m := range milestones {
+			if m.DueOn != nil && (next == nil || m.DueOn.Before(*next.DueOn)) {
+				next = &milestones[i]
+			}
+		}
+		if next == nil {
+			return milestone{}, false
+		}
+		return *next, true
+	}
+
+	idx := slices.IndexFunc(milestones, func(m milestone) bool {
+		return strings.EqualFold(m.Title, title)
+	})
+	if idx < 0 {
+		return milestone{}, false
+	}
+	return milestones[idx], true
+}
+
+// milestoneByRules returns the milestone of the first rule matching the labels.
+func milestoneByRules(rules []milestoneRule, milestones []milestone, labels []string) (milestone, string, bool) {
+	for _, r := range rules {
+		if !(routingRule{Labels: r.Labels}).matches(labels) {
+			continue
+		}
+		m, ok := findMilestone(milestones, r.Milestone)
+		if !ok {
+			log.Printf("No open milestone %q.", r.Milestone)
+			continue
+		}
+
+		explanation := fmt.Sprintf("Items labeled %s go to the milestone %q.", strings.Join(r.Labels, " or "), r.Milestone)
+		if strings.EqualFold(r.Milestone, nextMilestone) {
+			explanation = fmt.Sprintf("Items labeled %s go to the next milestone by due date.", strings.Join(r.Labels, " or "))
+		}
+		return m, explanation, true
+	}
+	return milestone{}, "", false
+}
+
+// milestoneVerdict is the milestone chosen by the model.
+type milestoneVerdict struct {
+	// Milestone is the title of the milestone or empty if none fits.
+	Milestone   string `json:"milestone"`
+	Explanation string `json:"explanation"`
+}
+
+// ChooseMilestone asks the model which of the open milestones the item belongs to.
+func (a labelingAssistant) ChooseMilestone(
+	ctx context.Context, payload string, labels []string, milestones []milestone,
+) (milestoneVerdict, error) {
+	systemPrompt := `You are the developer planning work on GitHub.
+You will receive an issue with its labels. Choose the open milestone it belongs to, if any.
+
+Open milestones:
+`
+	for _, m := range milestones {
+		due := "no due date"
+		if m.DueOn != nil {
+			due = "due " + m.DueOn.Format(time.DateOnly)
+		}
+		systemPrompt += fmt.Sprintf("- %s (%s): %s\n", m.Title, due, m.Description)
+	}
+	systemPrompt += `
+Provide the answer as json. For example:
+{
+  "milestone": "v1.2.0",
+  "explanation": "A regression in the current release is fixed in the next patch release."
+}
+
+The "milestone" field is the title of one of the milestones or empty if none fits.
+`
+
+	content := fmt.Sprintf("Labels: %s\n%s", strings.Join(labels, ", "), payload)
+
+	var verdict milestoneVerdict
+	_, err := a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: truncated(content, a.budget.body(systemPrompt))},
+	}, &verdict)
+	if err != nil {
+		return milestoneVerdict{}, fmt.Errorf("failed to choose milestone: %w", err)
+	}
+	return verdict, nil
+}
+
+// setMilestone sets the milestone of the issue or the pull request and returns the comment section explaining it.
+func setMilestone(
+	ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, assistant *labelingAssistant,
+	mc milestoneConfig, p payload, labels []string,
+) (string, error) {
+	kind := p.kind(cfg.eventName)
+	if !mc.enabled() || (kind != "issue" && kind != "pull_request") {
+		return "", nil
+	}
+	if p.milestone != "" {
+		log.Printf("The milestone %q is already set.", p.milestone)
+		return "", nil
+	}
+
+	milestones, err := ghapi.FetchOpenMilestones(ctx, cfg.repoOwner, cfg.repoName)
+	if err != nil {
+		return "", err
+	}
+	if len(milestones) == 0 {
+		return "", nil
+	}
+
+	var (
+		chosen      milestone
+		explanation string
+		ok          bool
+	)
+	if mc.Strategy == milestoneStrategyModel {
+		verdict, err := assistant.ChooseMilestone(ctx, p.String(), labels, milestones)
+		if err != nil {
+			return "", err
+		}
+		explanation = verdict.Explanation
+		if verdict.Milestone != "" {
+			chosen, ok = findMilestone(milestones, verdict.Milestone)
+		}
+	} else {
+		chosen, explanation, ok = milestoneByRules(mc.Rules, milestones, labels)
+	}
+
+	if !ok {
+		log.Println("No milestone fits.")
+		return "", nil
+	}
+
+	if err := ghapi.SetMilestone(ctx, p.nodeID, chosen.ID, kind == "pull_request"); err != nil {
+		return "", err
+	}
+	log.Printf("The milestone %q is set.", chosen.Title)
+
+	return fmt.Sprintf("**Milestone:** %s — %s\n", chosen.Title, explanation), nil
+}
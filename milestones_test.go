@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMilestones() []milestone {
+	due := func(s string) *time.Time {
+		t, _ := time.Parse(time.DateOnly, s)
+		return &t
+	}
+	return []milestone{
+		{ID: "M_1", Title: "Backlog"},
+		{ID: "M_2", Title: "v1.3", DueOn: due("2024-06-01")},
+		{ID: "M_3", Title: "v1.2", DueOn: due("2024-03-01")},
+	}
+}
+
+func TestFindMilestone(t *testing.T) {
+	milestones := testMilestones()
+
+	m, ok := findMilestone(milestones, "next")
+	require.True(t, ok)
+	assert.Equal(t, "v1.2", m.Title)
+
+	m, ok = findMilestone(milestones, "backlog")
+	require.True(t, ok)
+	assert.Equal(t, "M_1", m.ID)
+
+	_, ok = findMilestone(milestones, "v2.0")
+	assert.False(t, ok)
+
+	_, ok = findMilestone(milestones[:1], "next")
+	assert.False(t, ok)
+}
+
+func TestMilestoneByRules(t *testing.T) {
+	rules := []milestoneRule{
+		{Labels: []string{"priority/critical"}, Milestone: "next"},
+		{Labels: []string{"enhancement"}, Milestone: "v2.0"},
+		{Labels: []string{"enhancement", "question"}, Milestone: "Backlog"},
+	}
+
+	m, explanation, ok := milestoneByRules(rules, testMilestones(), []string{"bug", "priority/critical"})
+	require.True(t, ok)
+	assert.Equal(t, "v1.2", m.Title)
+	assert.Equal(t, "Items labeled priority/critical go to the next milestone by due date.", explanation)
+
+	m, explanation, ok = milestoneByRules(rules, testMilestones(), []string{"enhancement"})
+	require.True(t, ok)
+	assert.Equal(t, "Backlog", m.Title)
+	assert.Equal(t, `Items labeled enhancement or question go to the milestone "Backlog".`, explanation)
+
+	_, _, ok = milestoneByRules(rules, testMilestones(), []string{"docs"})
+	assert.False(t, ok)
+}
+
+func TestSetMilestone(t *testing.T) {
+	cfg := config{eventName: "issues", repoOwner: "owner", repoName: "repo"}
+	milestones := `{"data":{"repository":{"milestones":{"nodes":[{"id":"M_1","title":"Backlog","dueOn":null},{"id":"M_2","title":"v1.2","dueOn":"2024-03-01T00:00:00Z"}]}}}}`
+
+	t.Run("model", func(t *testing.T) {
+		assistant := fakeAssistant(t, `{"milestone":"v1.2","explanation":"A regression."}`)
+
+		transport := &sequenceTransport{responses: []string{milestones, `{"data":{}}`}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		mc := milestoneConfig{Strategy: milestoneStrategyModel}
+		section, err := setMilestone(context.TODO(), cfg, ghapi, assistant, mc, payload{nodeID: "I_1"}, []string{"bug"})
+		require.NoError(t, err)
+		assert.Equal(t, "**Milestone:** v1.2 — A regression.\n", section)
+		require.Len(t, transport.requests, 2)
+		assert.Contains(t, transport.requests[1], "updateIssue")
+		assert.Contains(t, transport.requests[1], `"milestoneId":"M_2"`)
+	})
+
+	t.Run("pull request comment", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{milestones, `{"data":{}}`}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		commentCfg := cfg
+		commentCfg.eventName = "issue_comment"
+		mc := milestoneConfig{Rules: []milestoneRule{{Labels: []string{"bug"}, Milestone: "v1.2"}}}
+		_, err := setMilestone(context.TODO(), commentCfg, ghapi, nil, mc, payload{nodeID: "PR_1", pullRequest: true}, []string{"bug"})
+		require.NoError(t, err)
+		require.Len(t, transport.requests, 2)
+		assert.Contains(t, transport.requests[1], "updatePullRequest")
+	})
+
+	t.Run("already set", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{milestones}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		mc := milestoneConfig{Rules: []milestoneRule{{Labels: []string{"bug"}, Milestone: "next"}}}
+		section, err := setMilestone(context.TODO(), cfg, ghapi, nil, mc, payload{nodeID: "I_1", milestone: "v1.0"}, []string{"bug"})
+		require.NoError(t, err)
+		assert.Empty(t, section)
+		assert.Zero(t, transport.calls)
+	})
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultSpamLabel      = "spam"
+	defaultSpamConfidence = 0.9
+
+	spamActionClose    = "close"
+	spamActionLock     = "lock"
+	spamActionMinimize = "minimize"
+)
+
+// trustedAssociations are never moderated.
+var trustedAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+// spamVerdict is the answer of the model about whether the content is spam.
+type spamVerdict struct {
+	Spam        bool    `json:"spam"`
+	Confidence  float64 `json:"confidence"`
+	Explanation string  `json:"explanation"`
+}
+
+func (v spamVerdict) detected(threshold float64) bool {
+	return v.Spam && v.Confidence >= threshold
+}
+
+// ClassifySpam asks the model whether the content is spam or abuse.
+func (a labelingAssistant) ClassifySpam(ctx context.Context, content string) (spamVerdict, error) {
+	systemPrompt := `You are a moderator of a GitHub repository.
+You will receive an issue, a discussion or a comment. Decide whether it is spam or abuse:
+advertising, SEO links, unrelated promotional content, gibberish, scams, harassment.
+Low-quality or off-topic questions from real users are not spam.
+
+Provide the answer as json. For example:
+{
+  "spam": true,
+  "confidence": 0.95,
+  "explanation": "The text advertises a casino and has nothing to do with the project."
+}
+
+The "confidence" field is a number from 0 to 1.
+`
+
+	var verdict spamVerdict
+	_, err := a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: truncated(content, a.budget.body(systemPrompt))},
+	}, &verdict)
+	if err != nil {
+		return spamVerdict{}, fmt.Errorf("failed to classify spam: %w", err)
+	}
+	return verdict, nil
+}
+
+// parseSpamActions validates the comma-separated list of moderation actions.
+func parseSpamActions(actions []string) ([]string, error) {
+	var parsed []string
+	for _, a := range actions {
+		a = strings.TrimSpace(a)
+		switch a {
+		case "":
+			continue
+		case spamActionClose, spamActionLock, spamActionMinimize:
+			parsed = append(parsed, a)
+		default:
+			return nil, fmt.Errorf("unknown spam action %q", a)
+		}
+	}
+	return parsed, nil
+}
+
+// moderationExempt reports whether the author is trusted and must not be moderated.
+func (c fileConfig) moderationExempt(a author) bool {
+	return matchAssociation(a.association, trustedAssociations) || matchLogin(a.login, c.ModerationAllowlist)
+}
+
+// moderateItem classifies the item and, if it is spam, labels it and applies the moderation actions.
+// It reports whether the item was moderated, in which case it must not be labeled.
+func moderateItem(
+	ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, assistant *labelingAssistant, p payload, actions []string,
+) (bool, error) {
+	kind := p.kind(cfg.eventName)
+
+	verdict, err := assistant.ClassifySpam(ctx, p.String())
+	if err != nil {
+		return false, err
+	}
+
+	auditSpam(kind, p.nodeID, p.author.login, verdict, "classified")
+	if !verdict.detected(cfg.spamConfidence) {
+		return false, nil
+	}
+
+	// nothing is changed in the suggest mode, the decisions are left in the workflow logs for maintainers
+	if cfg.mode == modeSuggest {
+		for _, action := range append([]string{"label " + cfg.spamLabel}, actions...) {
+			auditSpam(kind, p.nodeID, p.author.login, verdict, "suggested "+action)
+		}
+		return true, nil
+	}
+
+	repoLabels, err := ghapi.FetchRepoLabels(ctx, cfg.repoOwner, cfg.repoName)
+	if err != nil {
+		return false, err
+	}
+
+	labelIDs := labelIDsByName(filterLabels(repoLabels, cfg.excludedLabels), []string{cfg.spamLabel})
+	if len(labelIDs) == 0 {
+		log.Printf("The %q label is not available.", cfg.spamLabel)
+	} else {
+		if err := ghapi.ReplaceLabels(ctx, p.nodeID, labelIDs); err != nil {
+			return false, err
+		}
+		auditSpam(kind, p.nodeID, p.author.login, verdict, "labeled "+cfg.spamLabel)
+	}
+
+	for _, action := range actions {
+		switch {
+		case action == spamActionClose && kind == "discussion":
+			err = ghapi.CloseDiscussion(ctx, p.nodeID)
+		case action == spamActionClose && kind == "pull_request":
+			err = ghapi.ClosePullRequest(ctx, p.nodeID)
+		case action == spamActionClose:
+			err = ghapi.CloseIssue(ctx, p.nodeID)
+		case action == spamActionLock:
+			err = ghapi.LockLockable(ctx, p.nodeID)
+		default:
+			log.Printf("The %s is not minimized, GitHub can minimize only comments.", kind)
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		auditSpam(kind, p.nodeID, p.author.login, verdict, action)
+	}
+	return true, nil
+}
+
+// moderateComment classifies the comment and minimizes it as spam if the minimize action is enabled.
+func moderateComment(
+	ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, assistant *labelingAssistant, c *comment, actions []string,
+) error {
+	verdict, err := assistant.ClassifySpam(ctx, "Comment: "+c.body)
+	if err != nil {
+		return err
+	}
+
+	auditSpam("comment", c.nodeID, c.author, verdict, "classified")
+	if !verdict.detected(cfg.spamConfidence) || !slices.Contains(actions, spamActionMinimize) {
+		return nil
+	}
+
+	if cfg.mode == modeSuggest {
+		auditSpam("comment", c.nodeID, c.author, verdict, "suggested "+spamActionMinimize)
+		return nil
+	}
+
+	if err := ghapi.MinimizeComment(ctx, c.nodeID); err != nil {
+		return err
+	}
+	auditSpam("comment", c.nodeID, c.author, verdict, spamActionMinimize)
+	return nil
+}
+
+// auditSpam logs every moderation decision, so that they can be reviewed in the workflow logs.
+func auditSpam(kind, nodeID, author string, v spamVerdict, action string) {
+	log.Printf("moderation: kind=%s id=%s author=%q spam=%t confidence=%.2f action=%q reason=%q",
+		kind, nodeID, author, v.Spam, v.Confidence, action, v.Explanation)
+}
+
+// moderate checks the item or the comment that triggered the event for spam.
+// It reports whether the content was moderated and must not be processed further.
+func moderate(
+	ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, fileCfg fileConfig, p payload, actions []string,
+) (bool, error) {
+	assistant := newLabeler(cfg, ghapi).assistant
+
+	if c := p.comment; c != nil {
+		if p.action != "created" || fileCfg.moderationExempt(author{login: c.author, association: c.association}) {
+			return false, nil
+		}
+		// commands are checked against the permissions of their authors
+		if _, isCommand, _ := parseSlashCommand(c.body); isCommand {
+			return false, nil
+		}
+
+		err := moderateComment(ctx, cfg, ghapi, assistant, c, actions)
+		// a comment that is not a command needs no further processing anyway,
+		// except for the approvals of suggestions which spammers cannot give
+		return false, err
+	}
+
+	if p.author.isBot || fileCfg.moderationExempt(p.author) {
+		return false, nil
+	}
+	if p.action == "edited" && !p.contentChanged() {
+		return false, nil
+	}
+	return moderateItem(ctx, cfg, ghapi, assistant, p, actions)
+}
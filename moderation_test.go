@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpamActions(t *testing.T) {
+	actions, err := parseSpamActions([]string{""})
+	require.NoError(t, err)
+	assert.Empty(t, actions)
+
+	actions, err = parseSpamActions([]string{"close", " lock", "minimize"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"close", "lock", "minimize"}, actions)
+
+	_, err = parseSpamActions([]string{"delete"})
+	require.Error(t, err)
+}
+
+func TestModerationExempt(t *testing.T) {
+	cfg := fileConfig{ModerationAllowlist: []string{"trusted-*"}}
+
+	assert.True(t, cfg.moderationExempt(author{login: "someone", association: "MEMBER"}))
+	assert.True(t, cfg.moderationExempt(author{login: "trusted-user", association: "NONE"}))
+	assert.False(t, cfg.moderationExempt(author{login: "someone", association: "FIRST_TIME_CONTRIBUTOR"}))
+}
+
+func TestModerateItem(t *testing.T) {
+	cfg := config{eventName: "issues", repoOwner: "owner", repoName: "repo", spamLabel: "spam", spamConfidence: 0.9}
+	p := payload{nodeID: "I_1", title: "Best casino", author: author{login: "spammer"}}
+
+	t.Run("spam", func(t *testing.T) {
+		transport := &sequenceTransport{
+			responses: []string{
+				`{"data":{"repository":{"labels":{"nodes":[{"id":"L_1","name":"spam"}]}}}}`,
+				`{"data":{}}`,
+			},
+		}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		assistant := fakeAssistant(t, `{"spam":true,"confidence":0.95,"explanation":"Advertising."}`)
+
+		moderated, err := moderateItem(context.TODO(), cfg, ghapi, assistant, p, []string{"close", "lock", "minimize"})
+		require.NoError(t, err)
+		assert.True(t, moderated)
+
+		require.Len(t, transport.requests, 4)
+		assert.Contains(t, transport.requests[1], "addLabelsToLabelable")
+		assert.Contains(t, transport.requests[2], "closeIssue")
+		assert.Contains(t, transport.requests[3], "lockLockable")
+	})
+
+	t.Run("pull request", func(t *testing.T) {
+		transport := &sequenceTransport{
+			responses: []string{
+				`{"data":{"repository":{"labels":{"nodes":[{"id":"L_1","name":"spam"}]}}}}`,
+				`{"data":{}}`,
+			},
+		}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		assistant := fakeAssistant(t, `{"spam":true,"confidence":0.95,"explanation":"Advertising."}`)
+
+		prCfg := cfg
+		prCfg.eventName = "pull_request"
+		moderated, err := moderateItem(context.TODO(), prCfg, ghapi, assistant, payload{nodeID: "PR_1", title: "Best casino"}, []string{"close"})
+		require.NoError(t, err)
+		assert.True(t, moderated)
+
+		require.Len(t, transport.requests, 3)
+		assert.Contains(t, transport.requests[2], "closePullRequest")
+		assert.NotContains(t, transport.requests[2], "closeIssue")
+	})
+
+	t.Run("suggest mode", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{`{"data":{}}`}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		assistant := fakeAssistant(t, `{"spam":true,"confidence":0.95,"explanation":"Advertising."}`)
+
+		suggestCfg := cfg
+		suggestCfg.mode = modeSuggest
+		moderated, err := moderateItem(context.TODO(), suggestCfg, ghapi, assistant, p, []string{"close", "lock"})
+		require.NoError(t, err)
+		assert.True(t, moderated)
+		assert.Zero(t, transport.calls)
+	})
+
+	t.Run("not confident", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{`{"data":{}}`}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		assistant := fakeAssistant(t, `{"spam":true,"confidence":0.6,"explanation":"Maybe."}`)
+
+		moderated, err := moderateItem(context.TODO(), cfg, ghapi, assistant, p, []string{"close"})
+		require.NoError(t, err)
+		assert.False(t, moderated)
+		assert.Zero(t, transport.calls)
+	})
+}
+
+func TestModerateComment(t *testing.T) {
+	cfg := config{spamConfidence: 0.9}
+	c := &comment{nodeID: "IC_1", body: "Buy followers", author: "spammer"}
+
+	transport := &sequenceTransport{responses: []string{`{"data":{}}`}}
+	ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+	assistant := fakeAssistant(t, `{"spam":true,"confidence":0.95,"explanation":"Advertising."}`)
+
+	require.NoError(t, moderateComment(context.TODO(), cfg, ghapi, assistant, c, []string{"close"}))
+	assert.Zero(t, transport.calls)
+
+	suggestCfg := cfg
+	suggestCfg.mode = modeSuggest
+	require.NoError(t, moderateComment(context.TODO(), suggestCfg, ghapi, assistant, c, []string{"minimize"}))
+	assert.Zero(t, transport.calls)
+
+	require.NoError(t, moderateComment(context.TODO(), cfg, ghapi, assistant, c, []string{"minimize"}))
+	require.Len(t, transport.requests, 1)
+	assert.Contains(t, transport.requests[0], "minimizeComment")
+}
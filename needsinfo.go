@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const defaultNeedsInfoLabel = "needs-info"
+
+// completenessVerdict is the answer of the model about the information missing from the item.
+type completenessVerdict struct {
+	Missing   []string `json:"missing"`
+	Questions []string `json:"questions"`
+}
+
+func (v completenessVerdict) complete() bool {
+	return len(v.Missing) == 0
+}
+
+// requiredFields returns the non-empty fields of the comma-separated list.
+func requiredFields(fields []string) []string {
+	var required []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			required = append(required, f)
+		}
+	}
+	return required
+}
+
+// CheckCompleteness asks the model which of the required fields the item lacks.
+func (a labelingAssistant) CheckCompleteness(ctx context.Context, payload string, fields []string) (completenessVerdict, error) {
+	systemPrompt := fmt.Sprintf(`You are the developer triaging issues on GitHub.
+You will receive an issue. Check whether it provides the following information:
+- %s
+
+Consider only the information that is relevant to the issue, e.g. a feature request needs no logs.
+For every missing piece of information, ask the author a specific follow-up question.
+
+Provide the answer as json. For example:
+{
+  "missing": ["version"],
+  "questions": ["Which version of the tool do you use?"]
+}
+
+Both lists are empty if the issue provides all the relevant information.
+`, strings.Join(fields, "\n- "))
+
+	var verdict completenessVerdict
+	_, err := a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: truncated(payload, a.budget.body(systemPrompt))},
+	}, &verdict)
+	if err != nil {
+		return completenessVerdict{}, fmt.Errorf("failed to check completeness: %w", err)
+	}
+	return verdict, nil
+}
+
+// formatQuestions renders the comment section with the follow-up questions.
+func formatQuestions(v completenessVerdict) string {
+	section := "**More information needed:** please edit the description to answer the following questions:\n"
+	for _, q := range v.Questions {
+		section += fmt.Sprintf("- %s\n", q)
+	}
+	if len(v.Questions) == 0 {
+		section += fmt.Sprintf("- Please provide: %s\n", strings.Join(v.Missing, ", "))
+	}
+	return section
+}
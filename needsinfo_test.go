@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiredFields(t *testing.T) {
+	assert.Empty(t, requiredFields([]string{""}))
+	assert.Equal(t, []string{"version", "logs"}, requiredFields([]string{"version", " logs ", ""}))
+}
+
+func TestFormatQuestions(t *testing.T) {
+	t.Run("questions", func(t *testing.T) {
+		v := completenessVerdict{Missing: []string{"version"}, Questions: []string{"Which version do you use?"}}
+		assert.Equal(t,
+			"**More information needed:** please edit the description to answer the following questions:\n"+
+				"- Which version do you use?\n",
+			formatQuestions(v),
+		)
+	})
+
+	t.Run("no questions", func(t *testing.T) {
+		v := completenessVerdict{Missing: []string{"version", "logs"}}
+		assert.Contains(t, formatQuestions(v), "- Please provide: version, logs\n")
+	})
+}
+
+func TestCheckCompleteness(t *testing.T) {
+	assistant := fakeAssistant(t, `{"missing":["logs"],"questions":["Can you attach the logs?"]}`)
+
+	verdict, err := assistant.CheckCompleteness(context.TODO(), "Title: Crash", []string{"version", "logs"})
+	require.NoError(t, err)
+	assert.False(t, verdict.complete())
+	assert.Equal(t, []string{"Can you attach the logs?"}, verdict.Questions)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// existingLabelsPolicy defines what to do with items that already have labels,
+// e.g. set by issue templates or by maintainers.
+type existingLabelsPolicy string
+
+const (
+	// existingIgnore labels the item regardless of its labels.
+	existingIgnore existingLabelsPolicy = "ignore"
+	// existingSkip skips the item if it has any label.
+	existingSkip existingLabelsPolicy = "skip"
+	// existingSkipGroup skips the item if it has a label from one of the label groups.
+	existingSkipGroup existingLabelsPolicy = "skip-group"
+	// existingFillGroups offers only the labels of the groups the item has no labels from.
+	existingFillGroups existingLabelsPolicy = "fill-groups"
+)
+
+func parseExistingLabelsPolicy(s string) (existingLabelsPolicy, error) {
+	switch p := existingLabelsPolicy(s); p {
+	case existingIgnore, existingSkip, existingSkipGroup, existingFillGroups:
+		return p, nil
+	case "":
+		return existingIgnore, nil
+	default:
+		return "", fmt.Errorf("unknown existing labels policy %q", s)
+	}
+}
+
+// requireGroups checks that the label groups are set for the policies that depend on them.
+// Without groups, fill-groups would skip every labeled item and skip-group none.
+func requireGroups(policy existingLabelsPolicy, groups []string) error {
+	if policy != existingFillGroups && policy != existingSkipGroup {
+		return nil
+	}
+	for _, g := range groups {
+		if strings.TrimSpace(g) != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("the existing labels policy %q requires label groups", policy)
+}
+
+// labelGroup returns the group prefix of the label or an empty string if the label is not in any group.
+func labelGroup(label string, groups []string) string {
+	for _, g := range groups {
+		if g != "" && strings.HasPrefix(strings.ToLower(label), strings.ToLower(g)) {
+			return g
+		}
+	}
+	return ""
+}
+
+// scopeLabels applies the policy to the candidates of the model only. The labels added by the other
+// stages, e.g. needs-info or the priority, are returned separately and only exclude the existing labels.
+func scopeLabels(
+	policy existingLabelsPolicy, groups []string, existing []string, available []Label,
+) (candidates []Label, stageLabels []Label, skip bool) {
+	candidates, skip = applyExistingLabelsPolicy(policy, groups, existing, available)
+	return candidates, filterLabels(available, existing), skip
+}
+
+// applyExistingLabelsPolicy returns the labels that may be offered to the model
+// and reports whether the item must be skipped.
+func applyExistingLabelsPolicy(
+	policy existingLabelsPolicy, groups []string, existing []string, available []Label,
+) ([]Label, bool) {
+	if len(existing) == 0 {
+		return available, false
+	}
+
+	switch policy {
+	case existingSkip:
+		return nil, true
+	case existingSkipGroup:
+		for _, l := range existing {
+			if labelGroup(l, groups) != "" {
+				return nil, true
+			}
+		}
+	case existingFillGroups:
+		filled := make(map[string]bool)
+		for _, l := range existing {
+			if g := labelGroup(l, groups); g != "" {
+				filled[g] = true
+			}
+		}
+
+		var missing []Label
+		for _, l := range available {
+			if g := labelGroup(l.Name, groups); g != "" && !filled[g] {
+				missing = append(missing, l)
+			}
+		}
+		return missing, len(missing) == 0
+	}
+
+	// there is no point in offering the labels the item already has
+	return filterLabels(available, existing), false
+}
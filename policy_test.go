@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyExistingLabelsPolicy(t *testing.T) {
+	available := []Label{
+		{Name: "kind/bug"},
+		{Name: "kind/feature"},
+		{Name: "area/cli"},
+		{Name: "area/api"},
+		{Name: "good first issue"},
+	}
+	groups := []string{"kind/", "area/"}
+
+	tests := []struct {
+		name     string
+		policy   existingLabelsPolicy
+		existing []string
+		expected []Label
+		skip     bool
+	}{
+		{
+			name:     "no existing labels",
+			policy:   existingSkip,
+			expected: available,
+		},
+		{
+			name:     "ignore",
+			policy:   existingIgnore,
+			existing: []string{"kind/bug"},
+			expected: []Label{{Name: "kind/feature"}, {Name: "area/cli"}, {Name: "area/api"}, {Name: "good first issue"}},
+		},
+		{
+			name:     "skip",
+			policy:   existingSkip,
+			existing: []string{"good first issue"},
+			skip:     true,
+		},
+		{
+			name:     "skip group",
+			policy:   existingSkipGroup,
+			existing: []string{"area/cli"},
+			skip:     true,
+		},
+		{
+			name:     "skip group with ungrouped label",
+			policy:   existingSkipGroup,
+			existing: []string{"good first issue"},
+			expected: []Label{{Name: "kind/bug"}, {Name: "kind/feature"}, {Name: "area/cli"}, {Name: "area/api"}},
+		},
+		{
+			name:     "fill groups",
+			policy:   existingFillGroups,
+			existing: []string{"kind/bug"},
+			expected: []Label{{Name: "area/cli"}, {Name: "area/api"}},
+		},
+		{
+			name:     "all groups filled",
+			policy:   existingFillGroups,
+			existing: []string{"kind/bug", "area/api"},
+			skip:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, skip := applyExistingLabelsPolicy(tt.policy, groups, tt.existing, available)
+			assert.Equal(t, tt.skip, skip)
+			if !tt.skip {
+				assert.Equal(t, tt.expected, labels)
+			}
+		})
+	}
+}
+
+func TestParseExistingLabelsPolicy(t *testing.T) {
+	p, err := parseExistingLabelsPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, existingIgnore, p)
+
+	p, err = parseExistingLabelsPolicy("fill-groups")
+	require.NoError(t, err)
+	assert.Equal(t, existingFillGroups, p)
+
+	_, err = parseExistingLabelsPolicy("unknown")
+	require.Error(t, err)
+}
+
+func TestRequireGroups(t *testing.T) {
+	require.NoError(t, requireGroups(existingIgnore, []string{""}))
+	require.NoError(t, requireGroups(existingFillGroups, []string{"kind/", "area/"}))
+	require.Error(t, requireGroups(existingFillGroups, []string{""}))
+	require.Error(t, requireGroups(existingSkipGroup, nil))
+}
+
+func TestScopeLabels(t *testing.T) {
+	available := []Label{{Name: "kind/bug"}, {Name: "area/cli"}, {Name: "needs-info"}}
+
+	candidates, stageLabels, skip := scopeLabels(existingFillGroups, []string{"kind/", "area/"}, []string{"kind/bug"}, available)
+	require.False(t, skip)
+	assert.Equal(t, []Label{{Name: "area/cli"}}, candidates)
+	assert.Equal(t, []Label{{Name: "area/cli"}, {Name: "needs-info"}}, stageLabels)
+
+	// needs-info is outside the unfilled groups, but is still applied by its own stage
+	r := getLabelsResponse{}.with(stageLabels, "needs-info", "Missing information.")
+	assert.Equal(t, []string{"needs-info"}, r.labelNames())
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const priorityPrefix = "priority/"
+
+// priorityConfig is the rubric the priority is estimated with.
+type priorityConfig struct {
+	// Criteria are assessed one by one before the priority is chosen.
+	Criteria []priorityCriterion `yaml:"criteria"`
+	// Levels are the priority labels from the most to the least urgent.
+	// By default, the repository labels starting with "priority/" are used.
+	Levels []priorityLevel `yaml:"levels"`
+}
+
+type priorityCriterion struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+type priorityLevel struct {
+	Label       string `yaml:"label"`
+	Description string `yaml:"description"`
+}
+
+var defaultPriorityCriteria = []priorityCriterion{
+	{Name: "user impact", Description: "How many users are affected and how badly."},
+	{Name: "workaround availability", Description: "Whether users can easily avoid the problem."},
+	{Name: "security relevance", Description: "Whether the problem affects the security of users."},
+	{Name: "regression", Description: "Whether it worked in a previous version."},
+}
+
+// rubric returns the criteria and the levels, falling back to the defaults and the repository labels.
+func (c priorityConfig) rubric(labels []Label) ([]priorityCriterion, []priorityLevel) {
+	criteria := c.Criteria
+	if len(criteria) == 0 {
+		criteria = defaultPriorityCriteria
+	}
+
+	levels := c.Levels
+	if len(levels) == 0 {
+		for _, l := range labels {
+			if strings.HasPrefix(strings.ToLower(l.Name), priorityPrefix) {
+				levels = append(levels, priorityLevel{Label: l.Name, Description: l.Description})
+			}
+		}
+	}
+	return criteria, levels
+}
+
+// existing returns the priority level among the labels of the item, or an empty string if there is none.
+func (c priorityConfig) existing(labels []string, repoLabels []Label) string {
+	_, levels := c.rubric(repoLabels)
+	for _, l := range labels {
+		if isPriorityLabel(l, levels) {
+			return l
+		}
+	}
+	return ""
+}
+
+// isPriorityLabel reports whether the label is one of the levels.
+func isPriorityLabel(name string, levels []priorityLevel) bool {
+	return slices.ContainsFunc(levels, func(l priorityLevel) bool {
+		return strings.EqualFold(l.Label, name)
+	})
+}
+
+// priorityVerdict is the answer of the model about the priority of the item.
+type priorityVerdict struct {
+	Label       string              `json:"label"`
+	Criteria    []criterionAnalysis `json:"criteria"`
+	Explanation string              `json:"explanation"`
+}
+
+type criterionAnalysis struct {
+	Name       string `json:"name"`
+	Assessment string `json:"assessment"`
+}
+
+// EstimatePriority asks the model to assess the item by the criteria and choose one of the levels.
+func (a labelingAssistant) EstimatePriority(
+	ctx context.Context, payload string, criteria []priorityCriterion, levels []priorityLevel,
+) (priorityVerdict, error) {
+	systemPrompt := `You are the developer triaging issues on GitHub.
+You will receive an issue. Assess it by each of the criteria and then choose exactly one priority.
+
+Criteria:
+`
+	for _, c := range criteria {
+		systemPrompt += fmt.Sprintf("- %s: %s\n", c.Name, c.Description)
+	}
+	systemPrompt += "\nPriorities from the most to the least urgent:\n"
+	for _, l := range levels {
+		systemPrompt += fmt.Sprintf("- %s: %s\n", l.Label, l.Description)
+	}
+	systemPrompt += `
+Provide the answer as json. For example:
+{
+  "label": "priority/high",
+  "criteria": [
+    {"name": "user impact", "assessment": "All users of the CLI are affected."},
+    {"name": "regression", "assessment": "It worked in the previous release."}
+  ],
+  "explanation": "A regression that affects many users."
+}
+
+The "label" field is one of the priorities and "criteria" has an assessment for every criterion.
+`
+
+	var verdict priorityVerdict
+	_, err := a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: truncated(payload, a.budget.body(systemPrompt))},
+	}, &verdict)
+	if err != nil {
+		return priorityVerdict{}, fmt.Errorf("failed to estimate priority: %w", err)
+	}
+	return verdict, nil
+}
+
+// formatPriority renders the comment section with the reasoning per criterion.
+func formatPriority(v priorityVerdict) string {
+	section := fmt.Sprintf("**Priority:** %s\n", v.Label)
+	for _, c := range v.Criteria {
+		section += fmt.Sprintf("- *%s*: %s\n", c.Name, c.Assessment)
+	}
+	if v.Explanation != "" {
+		section += "\n" + v.Explanation + "\n"
+	}
+	return section
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityRubric(t *testing.T) {
+	labels := []Label{
+		{Name: "bug"},
+		{Name: "priority/high", Description: "Fix in the next release"},
+		{Name: "Priority/low", Description: "Fix eventually"},
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		criteria, levels := priorityConfig{}.rubric(labels)
+		assert.Equal(t, defaultPriorityCriteria, criteria)
+		assert.Equal(t, []priorityLevel{
+			{Label: "priority/high", Description: "Fix in the next release"},
+			{Label: "Priority/low", Description: "Fix eventually"},
+		}, levels)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		cfg := priorityConfig{
+			Criteria: []priorityCriterion{{Name: "revenue"}},
+			Levels:   []priorityLevel{{Label: "P0"}, {Label: "P1"}},
+		}
+		criteria, levels := cfg.rubric(labels)
+		assert.Equal(t, cfg.Criteria, criteria)
+		assert.Equal(t, cfg.Levels, levels)
+		assert.True(t, isPriorityLabel("p0", levels))
+		assert.False(t, isPriorityLabel("priority/high", levels))
+	})
+}
+
+func TestPriorityExisting(t *testing.T) {
+	labels := []Label{{Name: "bug"}, {Name: "priority/high"}, {Name: "priority/low"}}
+
+	assert.Equal(t, "priority/low", priorityConfig{}.existing([]string{"bug", "priority/low"}, labels))
+	assert.Empty(t, priorityConfig{}.existing([]string{"bug"}, labels))
+	assert.Empty(t, priorityConfig{Levels: []priorityLevel{{Label: "P0"}}}.existing([]string{"priority/low"}, labels))
+}
+
+func TestEstimatePriority(t *testing.T) {
+	assistant := fakeAssistant(t,
+		`{"label":"priority/high","criteria":[{"name":"regression","assessment":"It worked before."}],"explanation":"A regression."}`)
+
+	verdict, err := assistant.EstimatePriority(context.TODO(), "Title: Crash", defaultPriorityCriteria,
+		[]priorityLevel{{Label: "priority/high"}, {Label: "priority/low"}})
+	require.NoError(t, err)
+	assert.Equal(t, "priority/high", verdict.Label)
+
+	assert.Equal(t,
+		"**Priority:** priority/high\n- *regression*: It worked before.\n\nA regression.\n",
+		formatPriority(verdict),
+	)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// projectRule adds items with any of the labels to the project and sets its fields.
+type projectRule struct {
+	Labels []string `yaml:"labels"`
+	// Owner is the organization or the user owning the project, the repository owner by default.
+	Owner string `yaml:"owner"`
+	// Project is the title of the project.
+	Project string `yaml:"project"`
+	// Fields map field names to values, e.g. {"Status": "Triage"}.
+	// Single select fields take the name of the option.
+	Fields map[string]string `yaml:"fields"`
+}
+
+func (r projectRule) matches(labels []string) bool {
+	return routingRule{Labels: r.Labels}.matches(labels)
+}
+
+// projectV2 is a project with its fields resolved by name.
+type projectV2 struct {
+	ID     string
+	Title  string
+	Fields []projectField
+}
+
+type projectField struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	DataType string `json:"dataType"`
+	Options  []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"options"`
+}
+
+// fieldValue converts the configured value to the ProjectV2FieldValue input of the field.
+func (f projectField) fieldValue(value string) (map[string]any, error) {
+	switch f.DataType {
+	case "SINGLE_SELECT":
+		for _, o := range f.Options {
+			if strings.EqualFold(o.Name, value) {
+				return map[string]any{"singleSelectOptionId": o.ID}, nil
+			}
+		}
+		return nil, fmt.Errorf("field %q has no option %q", f.Name, value)
+	case "TEXT":
+		return map[string]any{"text": value}, nil
+	case "NUMBER":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("field %q expects a number: %w", f.Name, err)
+		}
+		return map[string]any{"number": n}, nil
+	case "DATE":
+		return map[string]any{"date": value}, nil
+	default:
+		return nil, fmt.Errorf("field %q of type %s is not supported", f.Name, f.DataType)
+	}
+}
+
+func (p *projectV2) field(name string) (projectField, bool) {
+	idx := slices.IndexFunc(p.Fields, func(f projectField) bool {
+		return strings.EqualFold(f.Name, name)
+	})
+	if idx < 0 {
+		return projectField{}, false
+	}
+	return p.Fields[idx], true
+}
+
+// projectResolver resolves projects by title once per run.
+type projectResolver struct {
+	ghapi    *GitHubGraphQLClient
+	projects map[string]*projectV2
+}
+
+func (r *projectResolver) resolve(ctx context.Context, owner, title string) (*projectV2, error) {
+	key := owner + "/" + title
+	if p, ok := r.projects[key]; ok {
+		return p, nil
+	}
+
+	p, err := r.ghapi.FetchProject(ctx, owner, title)
+	if err != nil {
+		return nil, err
+	}
+	r.projects[key] = p
+	return p, nil
+}
+
+// projectUpdate is the project an item is added to with the values of its fields.
+type projectUpdate struct {
+	project *projectV2
+	fields  []fieldUpdate
+}
+
+type fieldUpdate struct {
+	fieldID string
+	value   map[string]any
+}
+
+// planProjects resolves the projects and the field values of the rules matching the labels,
+// so that a misconfigured rule fails before anything is changed.
+func planProjects(ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, rules []projectRule, labels []string) ([]projectUpdate, error) {
+	resolver := &projectResolver{ghapi: ghapi, projects: make(map[string]*projectV2)}
+
+	var updates []projectUpdate
+	for _, rule := range rules {
+		if !rule.matches(labels) {
+			continue
+		}
+
+		owner := rule.Owner
+		if owner == "" {
+			owner = cfg.repoOwner
+		}
+
+		project, err := resolver.resolve(ctx, owner, rule.Project)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(rule.Fields))
+		for name := range rule.Fields {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		update := projectUpdate{project: project}
+		for _, name := range names {
+			field, ok := project.field(name)
+			if !ok {
+				return nil, fmt.Errorf("project %q has no field %q", project.Title, name)
+			}
+
+			value, err := field.fieldValue(rule.Fields[name])
+			if err != nil {
+				return nil, err
+			}
+			update.fields = append(update.fields, fieldUpdate{fieldID: field.ID, value: value})
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// addToProjects adds the issue or the pull request to the projects routed by the chosen labels.
+// The fields are set only when the item is added, so that the changes made by maintainers are kept
+// when the item is labeled again.
+func addToProjects(ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, rules []projectRule, p payload, labels []string) error {
+	// discussions cannot be added to projects
+	if objectName(cfg.eventName) == "discussion" {
+		return nil
+	}
+
+	updates, err := planProjects(ctx, cfg, ghapi, rules, labels)
+	if err != nil || len(updates) == 0 {
+		return err
+	}
+
+	existing, err := ghapi.FetchItemProjects(ctx, p.nodeID)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		if slices.Contains(existing, u.project.ID) {
+			log.Printf("Already in the project %q.", u.project.Title)
+			continue
+		}
+
+		itemID, err := ghapi.AddProjectItem(ctx, u.project.ID, p.nodeID)
+		if err != nil {
+			return err
+		}
+		existing = append(existing, u.project.ID)
+		log.Printf("Added to the project %q.", u.project.Title)
+
+		for _, f := range u.fields {
+			if err := ghapi.UpdateProjectItemField(ctx, u.project.ID, itemID, f.fieldID, f.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
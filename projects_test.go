@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectFieldValue(t *testing.T) {
+	var status projectField
+	require.NoError(t, json.Unmarshal([]byte(`{"id":"F_1","name":"Status","dataType":"SINGLE_SELECT","options":[{"id":"O_1","name":"Triage"}]}`), &status))
+
+	tests := []struct {
+		name     string
+		field    projectField
+		value    string
+		expected map[string]any
+		wantErr  bool
+	}{
+		{name: "single select", field: status, value: "triage", expected: map[string]any{"singleSelectOptionId": "O_1"}},
+		{name: "unknown option", field: status, value: "Done", wantErr: true},
+		{name: "text", field: projectField{DataType: "TEXT"}, value: "CLI", expected: map[string]any{"text": "CLI"}},
+		{name: "number", field: projectField{DataType: "NUMBER"}, value: "3", expected: map[string]any{"number": 3.0}},
+		{name: "invalid number", field: projectField{DataType: "NUMBER"}, value: "three", wantErr: true},
+		{name: "date", field: projectField{DataType: "DATE"}, value: "2024-01-31", expected: map[string]any{"date": "2024-01-31"}},
+		{name: "iteration", field: projectField{DataType: "ITERATION"}, value: "Sprint 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.field.fieldValue(tt.value)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestAddToProjects(t *testing.T) {
+	cfg := config{eventName: "issues", repoOwner: "owner", repoName: "repo"}
+	rules := []projectRule{
+		{Labels: []string{"area/cli"}, Project: "Triage", Fields: map[string]string{"Status": "Triage", "Team": "CLI"}},
+		{Labels: []string{"area/docs"}, Project: "Docs"},
+	}
+
+	const projects = `{"data":{"repositoryOwner":{"projectsV2":{"nodes":[
+		{"id":"P_2","title":"Triage old","fields":{"nodes":[]}},
+		{"id":"P_1","title":"Triage","fields":{"nodes":[
+			{"id":"F_1","name":"Status","dataType":"SINGLE_SELECT","options":[{"id":"O_1","name":"Triage"}]},
+			{"id":"F_2","name":"Team","dataType":"TEXT"}
+		]}}
+	]}}}}`
+
+	t.Run("add", func(t *testing.T) {
+		transport := &sequenceTransport{
+			responses: []string{
+				projects,
+				`{"data":{"node":{"projectItems":{"nodes":[]}}}}`,
+				`{"data":{"addProjectV2ItemById":{"item":{"id":"PVTI_1"}}}}`,
+				`{"data":{}}`,
+			},
+		}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		require.NoError(t, addToProjects(context.TODO(), cfg, ghapi, rules, payload{nodeID: "I_1"}, []string{"area/cli"}))
+		require.Len(t, transport.requests, 5)
+		assert.Contains(t, transport.requests[0], `"owner":"owner"`)
+		assert.Contains(t, transport.requests[1], `"id":"I_1"`)
+		assert.Contains(t, transport.requests[2], `"contentId":"I_1","projectId":"P_1"`)
+		assert.Contains(t, transport.requests[3], `"fieldId":"F_1"`)
+		assert.Contains(t, transport.requests[3], `"singleSelectOptionId":"O_1"`)
+		assert.Contains(t, transport.requests[4], `"fieldId":"F_2"`)
+		assert.Contains(t, transport.requests[4], `"text":"CLI"`)
+	})
+
+	t.Run("already in the project", func(t *testing.T) {
+		transport := &sequenceTransport{
+			responses: []string{
+				projects,
+				`{"data":{"node":{"projectItems":{"nodes":[{"project":{"id":"P_1"}}]}}}}`,
+			},
+		}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		require.NoError(t, addToProjects(context.TODO(), cfg, ghapi, rules, payload{nodeID: "I_1"}, []string{"area/cli"}))
+		assert.Len(t, transport.requests, 2)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{projects}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+		invalid := append(slices.Clone(rules), projectRule{Labels: []string{"area/cli"}, Project: "Triage", Fields: map[string]string{"Size": "S"}})
+		require.Error(t, addToProjects(context.TODO(), cfg, ghapi, invalid, payload{nodeID: "I_1"}, []string{"area/cli"}))
+		assert.Len(t, transport.requests, 1, "nothing is changed")
+	})
+}
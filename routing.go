@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+const (
+	strategyRoundRobin = "round-robin"
+	strategyLoad       = "load"
+)
+
+// routingConfig assigns people to items by their labels.
+type routingConfig struct {
+	// Strategy selects a person among the candidates: "round-robin" (default) or "load".
+	Strategy string `yaml:"strategy"`
+	// MaxPerPerson, if positive, is the maximum number of open items assigned to a person.
+	MaxPerPerson int           `yaml:"max_per_person"`
+	Rules        []routingRule `yaml:"rules"`
+}
+
+// routingRule assigns one of the users or team members to items with any of the labels.
+type routingRule struct {
+	Labels []string `yaml:"labels"`
+	Users  []string `yaml:"users"`
+	// Teams are "org/team-slug" or "team-slug" of the repository owner.
+	Teams []string `yaml:"teams"`
+}
+
+func (r routingRule) matches(labels []string) bool {
+	return slices.ContainsFunc(r.Labels, func(l string) bool {
+		return slices.ContainsFunc(labels, func(chosen string) bool {
+			return strings.EqualFold(l, chosen)
+		})
+	})
+}
+
+func (c routingConfig) validate() error {
+	if c.Strategy != "" && c.Strategy != strategyRoundRobin && c.Strategy != strategyLoad {
+		return fmt.Errorf("unknown routing strategy %q", c.Strategy)
+	}
+	return nil
+}
+
+// selectPerson chooses one of the candidates. Round-robin rotates the candidates by the item number,
+// so consecutive items go to different people without keeping any state. Load-based selection
+// chooses the person with the fewest open items. People at the cap are never chosen.
+func selectPerson(candidates []string, number int, strategy string, load map[string]int, maxPerPerson int) (string, bool) {
+	if maxPerPerson > 0 {
+		candidates = slices.DeleteFunc(slices.Clone(candidates), func(c string) bool {
+			return load[c] >= maxPerPerson
+		})
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	if strategy == strategyLoad {
+		return slices.MinFunc(candidates, func(a, b string) int {
+			return load[a] - load[b]
+		}), true
+	}
+	return candidates[number%len(candidates)], true
+}
+
+// assignPeople assigns the issue or requests reviews on the pull request
+// from the people routed by the chosen labels.
+func assignPeople(ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, routing routingConfig, p payload, labels []string) error {
+	kind := p.kind(cfg.eventName)
+	if kind != "issue" && kind != "pull_request" {
+		return nil
+	}
+	if kind == "pull_request" && len(p.reviewers) > 0 {
+		log.Println("Reviews are already requested.")
+		return nil
+	}
+	if kind == "issue" && len(p.assignees) > 0 {
+		log.Println("The issue is already assigned.")
+		return nil
+	}
+
+	teams := make(map[string][]string)
+	load := make(map[string]int)
+	var selected []string
+
+	for _, rule := range routing.Rules {
+		if !rule.matches(labels) {
+			continue
+		}
+
+		candidates := slices.Clone(rule.Users)
+		for _, team := range rule.Teams {
+			org, slug, ok := strings.Cut(team, "/")
+			if !ok {
+				org, slug = cfg.repoOwner, team
+			}
+			if _, fetched := teams[team]; !fetched {
+				members, err := ghapi.FetchTeamMembers(ctx, org, slug)
+				if err != nil {
+					return err
+				}
+				teams[team] = members
+			}
+			candidates = append(candidates, teams[team]...)
+		}
+
+		// nobody reviews their own pull request
+		candidates = slices.DeleteFunc(candidates, func(c string) bool {
+			return strings.EqualFold(c, p.author.login) || slices.Contains(selected, c)
+		})
+		slices.Sort(candidates)
+		candidates = slices.Compact(candidates)
+
+		if routing.Strategy == strategyLoad || routing.MaxPerPerson > 0 {
+			for _, c := range candidates {
+				if _, counted := load[c]; counted {
+					continue
+				}
+				n, err := ghapi.CountOpenAssigned(ctx, cfg.repoOwner, cfg.repoName, c, kind == "pull_request")
+				if err != nil {
+					return err
+				}
+				load[c] = n
+			}
+		}
+
+		person, ok := selectPerson(candidates, p.number, routing.Strategy, load, routing.MaxPerPerson)
+		if !ok {
+			log.Printf("Nobody is available for the labels %s.", strings.Join(rule.Labels, ", "))
+			continue
+		}
+		selected = append(selected, person)
+		load[person]++
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+
+	userIDs, err := ghapi.FetchUserIDs(ctx, selected)
+	if err != nil {
+		return err
+	}
+
+	if kind == "pull_request" {
+		log.Printf("Requesting reviews from %s.", strings.Join(selected, ", "))
+		return ghapi.RequestReviews(ctx, p.nodeID, userIDs)
+	}
+
+	log.Printf("Assigning %s.", strings.Join(selected, ", "))
+	return ghapi.AddAssignees(ctx, p.nodeID, userIDs)
+}
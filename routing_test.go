@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutingRuleMatches(t *testing.T) {
+	rule := routingRule{Labels: []string{"area/cli", "area/docs"}}
+	assert.True(t, rule.matches([]string{"bug", "Area/CLI"}))
+	assert.False(t, rule.matches([]string{"bug"}))
+}
+
+func TestRoutingConfigValidate(t *testing.T) {
+	require.NoError(t, routingConfig{}.validate())
+	require.NoError(t, routingConfig{Strategy: strategyLoad}.validate())
+	require.Error(t, routingConfig{Strategy: "random"}.validate())
+}
+
+func TestSelectPerson(t *testing.T) {
+	candidates := []string{"alice", "bob", "carol"}
+	load := map[string]int{"alice": 3, "bob": 1, "carol": 1}
+
+	tests := []struct {
+		name         string
+		number       int
+		strategy     string
+		maxPerPerson int
+		expected     string
+	}{
+		{name: "round-robin", number: 4, strategy: strategyRoundRobin, expected: "bob"},
+		{name: "default strategy", number: 6, expected: "alice"},
+		{name: "round-robin with cap", number: 6, strategy: strategyRoundRobin, maxPerPerson: 3, expected: "bob"},
+		{name: "load", strategy: strategyLoad, expected: "bob"},
+		{name: "everybody at cap", strategy: strategyLoad, maxPerPerson: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			person, ok := selectPerson(candidates, tt.number, tt.strategy, load, tt.maxPerPerson)
+			assert.Equal(t, tt.expected != "", ok)
+			assert.Equal(t, tt.expected, person)
+		})
+	}
+}
+
+func TestAssignPeople(t *testing.T) {
+	cfg := config{eventName: "issues", repoOwner: "owner", repoName: "repo"}
+	routing := routingConfig{
+		Rules: []routingRule{
+			{Labels: []string{"area/cli"}, Users: []string{"alice", "bob"}},
+			{Labels: []string{"area/docs"}, Users: []string{"carol"}},
+		},
+	}
+
+	t.Run("assign", func(t *testing.T) {
+		transport := &sequenceTransport{
+			responses: []string{
+				`{"data":{"user":{"id":"U_bob"}}}`,
+				`{"data":{}}`,
+			},
+		}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		p := payload{nodeID: "I_1", number: 1, author: author{login: "dave"}}
+
+		require.NoError(t, assignPeople(context.TODO(), cfg, ghapi, routing, p, []string{"area/cli"}))
+		require.Len(t, transport.requests, 2)
+		assert.Contains(t, transport.requests[0], `"login":"bob"`)
+		assert.Contains(t, transport.requests[1], "addAssigneesToAssignable")
+		assert.Contains(t, transport.requests[1], `"assigneeIds":["U_bob"]`)
+	})
+
+	t.Run("already assigned", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{`{"data":{}}`}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		p := payload{nodeID: "I_1", assignees: []string{"erin"}}
+
+		require.NoError(t, assignPeople(context.TODO(), cfg, ghapi, routing, p, []string{"area/cli"}))
+		assert.Zero(t, transport.calls)
+	})
+
+	t.Run("reviews already requested", func(t *testing.T) {
+		transport := &sequenceTransport{responses: []string{`{"data":{}}`}}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		prCfg := cfg
+		prCfg.eventName = "pull_request"
+		p := payload{nodeID: "PR_1", reviewers: []string{"erin"}}
+
+		require.NoError(t, assignPeople(context.TODO(), prCfg, ghapi, routing, p, []string{"area/cli"}))
+		assert.Zero(t, transport.calls)
+	})
+
+	t.Run("pull request author", func(t *testing.T) {
+		transport := &sequenceTransport{
+			responses: []string{
+				`{"data":{"user":{"id":"U_carol"}}}`,
+				`{"data":{}}`,
+			},
+		}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		prCfg := cfg
+		prCfg.eventName = "pull_request"
+		// pull requests are routed to reviewers even if they are assigned
+		p := payload{nodeID: "PR_1", author: author{login: "alice"}, assignees: []string{"alice"}}
+		only := routingConfig{Rules: []routingRule{{Labels: []string{"area/cli"}, Users: []string{"alice", "carol"}}}}
+
+		require.NoError(t, assignPeople(context.TODO(), prCfg, ghapi, only, p, []string{"area/cli"}))
+		require.Len(t, transport.requests, 2)
+		assert.Contains(t, transport.requests[1], "requestReviews")
+		assert.Contains(t, transport.requests[1], `"userIds":["U_carol"]`)
+	})
+
+	t.Run("pull request comment", func(t *testing.T) {
+		transport := &sequenceTransport{
+			responses: []string{
+				`{"data":{"user":{"id":"U_carol"}}}`,
+				`{"data":{}}`,
+			},
+		}
+		ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+		commentCfg := cfg
+		commentCfg.eventName = "issue_comment"
+		p := payload{nodeID: "PR_1", pullRequest: true}
+
+		require.NoError(t, assignPeople(context.TODO(), commentCfg, ghapi, routing, p, []string{"area/docs"}))
+		require.Len(t, transport.requests, 2)
+		assert.Contains(t, transport.requests[1], "requestReviews")
+	})
+}
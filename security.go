@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultSecurityLabel      = "security"
+	defaultSecurityConfidence = 0.8
+
+	webhookTimeout = 10 * time.Second
+
+	defaultSecurityComment = `Thank you for the report! 🔒
+
+It looks like this {{.Kind}} may describe a security vulnerability. To protect our users, please do not share further details publicly and report it as described in our [security policy]({{.PolicyURL}}) instead. A maintainer will follow up.
+`
+)
+
+// securityVerdict is the answer of the model about whether the item reports a vulnerability.
+type securityVerdict struct {
+	Security    bool    `json:"security"`
+	Confidence  float64 `json:"confidence"`
+	Explanation string  `json:"explanation"`
+}
+
+func (v securityVerdict) detected(threshold float64) bool {
+	return v.Security && v.Confidence >= threshold
+}
+
+// DetectSecurityReport asks the model whether the item discloses a security vulnerability.
+func (a labelingAssistant) DetectSecurityReport(ctx context.Context, payload string) (securityVerdict, error) {
+	systemPrompt := `You are the developer triaging issues on GitHub.
+You will receive an issue. Decide whether it reports a security vulnerability that should have been disclosed privately,
+e.g. remote code execution, authentication bypass, injection, leaked credentials or a vulnerable dependency that is exploitable.
+Questions about security features and hardening requests are not vulnerability reports.
+
+Provide the answer as json. For example:
+{
+  "security": true,
+  "confidence": 0.9,
+  "explanation": "The issue describes an authentication bypass."
+}
+
+The "confidence" field is a number from 0 to 1.
+`
+
+	var verdict securityVerdict
+	_, err := a.completeJSON(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: truncated(payload, a.budget.body(systemPrompt))},
+	}, &verdict)
+	if err != nil {
+		return securityVerdict{}, fmt.Errorf("failed to detect security report: %w", err)
+	}
+	return verdict, nil
+}
+
+// securityReport describes the detected report for the webhook and the comment template.
+type securityReport struct {
+	// Text makes the payload readable by Slack-compatible webhooks.
+	Text        string  `json:"text"`
+	Repository  string  `json:"repository"`
+	Kind        string  `json:"kind"`
+	Number      int     `json:"number,omitempty"`
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	Confidence  float64 `json:"confidence"`
+	Explanation string  `json:"explanation"`
+	PolicyURL   string  `json:"-"`
+}
+
+func newSecurityReport(cfg config, p payload, v securityVerdict) securityReport {
+	repo := cfg.repoOwner + "/" + cfg.repoName
+	kind := objectName(cfg.eventName)
+	return securityReport{
+		Text:        fmt.Sprintf("Possible security report in %s: %s", repo, p.url),
+		Repository:  repo,
+		Kind:        kind,
+		Number:      p.number,
+		Title:       p.title,
+		URL:         p.url,
+		Confidence:  v.Confidence,
+		Explanation: v.Explanation,
+		PolicyURL:   fmt.Sprintf("https://github.com/%s/security/policy", repo),
+	}
+}
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// notifyWebhook posts the report as JSON to the webhook.
+func notifyWebhook(ctx context.Context, client *http.Client, url string, report securityReport) error {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode security report: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to notify webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("failed to notify webhook: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// securityComment renders the comment asking the author to follow the security policy.
+func securityComment(tmpl string, report securityReport) (string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = defaultSecurityComment
+	}
+
+	t, err := template.New("security").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse security comment template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, report); err != nil {
+		return "", fmt.Errorf("failed to render security comment: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// securityEscalated reports whether the item was escalated by a previous run or labeled as a security report.
+func securityEscalated(cfg config, p payload, previous *botComment) bool {
+	if previous != nil && previous.Marker.Security {
+		return true
+	}
+	return slices.ContainsFunc(p.labels, func(l string) bool { return strings.EqualFold(l, cfg.securityLabel) })
+}
+
+// escalateSecurityReport labels the security report and, instead of the usual comment that could draw attention to it,
+// notifies the webhook or asks the author to follow the security policy. The escalation is recorded in the marker
+// of the comment, so that later edits do not escalate the item again.
+func escalateSecurityReport(
+	ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, commentTemplate string,
+	p payload, v securityVerdict, repoLabels []Label, dryRun bool,
+) error {
+	log.Printf("The %s looks like a security report (confidence %.2f): %s", objectName(cfg.eventName), v.Confidence, v.Explanation)
+
+	if dryRun {
+		log.Println("Dry run, the security report is not escalated.")
+		return nil
+	}
+
+	marker := commentMarker{Hash: payloadHash(p), Security: true}
+	if cfg.mode == modeApply {
+		// the existing labels policy and the vetoes narrow only the topical labels, not the security one
+		labelIDs := labelIDsByName(filterLabels(repoLabels, cfg.excludedLabels), []string{cfg.securityLabel})
+		if len(labelIDs) == 0 {
+			log.Printf("The %q label is not available.", cfg.securityLabel)
+		} else if err := ghapi.ReplaceLabels(ctx, p.nodeID, labelIDs); err != nil {
+			return err
+		} else {
+			marker.Labels = []string{cfg.securityLabel}
+		}
+	}
+
+	report := newSecurityReport(cfg, p, v)
+
+	var comment string
+	if cfg.securityWebhook != "" {
+		if err := notifyWebhook(ctx, webhookClient, cfg.securityWebhook, report); err != nil {
+			return err
+		}
+		log.Println("The security webhook is notified.")
+
+		// the label marks the item as escalated, otherwise only the hidden marker is left
+		if len(marker.Labels) > 0 {
+			return nil
+		} else if cfg.mode == modeApply {
+			log.Println("The security report is not marked as escalated, it is escalated again on the next event.")
+			return nil
+		}
+	} else {
+		var err error
+		if comment, err = securityComment(commentTemplate, report); err != nil {
+			return err
+		}
+	}
+	comment += "\n" + marker.String()
+
+	addCommentFn := ghapi.AddComment
+	if report.Kind == "discussion" {
+		addCommentFn = ghapi.AddDiscussionComment
+	}
+	return addCommentFn(ctx, p.nodeID, strconv.Quote(comment))
+}
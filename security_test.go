@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityVerdictDetected(t *testing.T) {
+	assert.True(t, securityVerdict{Security: true, Confidence: 0.9}.detected(0.8))
+	assert.False(t, securityVerdict{Security: true, Confidence: 0.5}.detected(0.8))
+	assert.False(t, securityVerdict{Confidence: 0.9}.detected(0.8))
+}
+
+func TestNewSecurityReport(t *testing.T) {
+	cfg := config{eventName: "issues", repoOwner: "owner", repoName: "repo"}
+	p := payload{number: 7, title: "RCE", url: "https://github.com/owner/repo/issues/7"}
+
+	report := newSecurityReport(cfg, p, securityVerdict{Security: true, Confidence: 0.9, Explanation: "RCE."})
+	assert.Equal(t, securityReport{
+		Text:        "Possible security report in owner/repo: https://github.com/owner/repo/issues/7",
+		Repository:  "owner/repo",
+		Kind:        "issue",
+		Number:      7,
+		Title:       "RCE",
+		URL:         "https://github.com/owner/repo/issues/7",
+		Confidence:  0.9,
+		Explanation: "RCE.",
+		PolicyURL:   "https://github.com/owner/repo/security/policy",
+	}, report)
+}
+
+func TestSecurityComment(t *testing.T) {
+	report := securityReport{Kind: "issue", Title: "RCE", PolicyURL: "https://github.com/owner/repo/security/policy"}
+
+	t.Run("default", func(t *testing.T) {
+		comment, err := securityComment("", report)
+		require.NoError(t, err)
+		assert.Contains(t, comment, "this issue may describe a security vulnerability")
+		assert.Contains(t, comment, "[security policy](https://github.com/owner/repo/security/policy)")
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		comment, err := securityComment("See {{.PolicyURL}} about {{.Title}}.", report)
+		require.NoError(t, err)
+		assert.Equal(t, "See https://github.com/owner/repo/security/policy about RCE.", comment)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := securityComment("{{.Unknown", report)
+		require.Error(t, err)
+	})
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	var received securityReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(b, &received))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	}))
+	defer server.Close()
+
+	report := securityReport{Text: "Possible security report", Number: 7, PolicyURL: "ignored"}
+	require.NoError(t, notifyWebhook(context.TODO(), server.Client(), server.URL, report))
+	assert.Equal(t, securityReport{Text: "Possible security report", Number: 7}, received)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	require.Error(t, notifyWebhook(context.TODO(), failing.Client(), failing.URL, report))
+}
+
+func TestSecurityEscalated(t *testing.T) {
+	cfg := config{securityLabel: "security"}
+
+	assert.True(t, securityEscalated(cfg, payload{labels: []string{"Security"}}, nil))
+	assert.True(t, securityEscalated(cfg, payload{}, &botComment{Marker: commentMarker{Security: true}}))
+	assert.False(t, securityEscalated(cfg, payload{labels: []string{"bug"}}, &botComment{}))
+}
+
+func TestEscalateSecurityReport(t *testing.T) {
+	var notified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notified++
+	}))
+	defer server.Close()
+
+	transport := &sequenceTransport{responses: []string{`{"data":{"addComment":{"clientMutationId":null}}}`}}
+	ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+	// the label is not applied in the suggest mode, so the escalation is recorded in a hidden marker
+	cfg := config{eventName: "issues", mode: modeSuggest, securityLabel: "security", securityWebhook: server.URL}
+	err := escalateSecurityReport(context.TODO(), cfg, ghapi, "", payload{nodeID: "I_1"}, securityVerdict{Security: true}, nil, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, notified)
+	require.Len(t, transport.requests, 1)
+	assert.Contains(t, transport.requests[0], `\\\"security\\\":true`)
+
+	// the label is looked up among the repository labels, a missing one leaves no bare marker behind
+	transport = &sequenceTransport{responses: []string{`{"data":{"replaceLabelsForLabelable":{"clientMutationId":null}}}`}}
+	ghapi = NewGithubClient("token", "url", &http.Client{Transport: transport})
+	cfg.mode = modeApply
+	repoLabels := []Label{{ID: "L_1", Name: "security"}}
+	err = escalateSecurityReport(context.TODO(), cfg, ghapi, "", payload{nodeID: "I_1"}, securityVerdict{Security: true}, repoLabels, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, notified)
+	require.Len(t, transport.requests, 1)
+	assert.Contains(t, transport.requests[0], `L_1`)
+
+	transport.requests = nil
+	cfg.excludedLabels = []string{"security"}
+	err = escalateSecurityReport(context.TODO(), cfg, ghapi, "", payload{nodeID: "I_1"}, securityVerdict{Security: true}, repoLabels, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, notified)
+	assert.Empty(t, transport.requests)
+}
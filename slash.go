@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const slashCommandName = "/auto-label"
+
+// slashCommand is a request of a maintainer to label an item again, made in a comment:
+//
+//	/auto-label
+//	/auto-label --details "It is about the CLI"
+//	/auto-label dry-run
+type slashCommand struct {
+	details string
+	dryRun  bool
+}
+
+// parseSlashCommand looks for the command on the first line of the comment.
+func parseSlashCommand(body string) (slashCommand, bool, error) {
+	line, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	if fields := strings.Fields(line); len(fields) == 0 || fields[0] != slashCommandName {
+		return slashCommand{}, false, nil
+	}
+
+	// the line is a command, so a malformed one must be reported rather than ignored
+	args, err := splitArgs(strings.TrimSpace(line))
+	if err != nil {
+		return slashCommand{}, true, fmt.Errorf("%s: %w", slashCommandName, err)
+	}
+
+	var cmd slashCommand
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "dry-run", "--dry-run":
+			cmd.dryRun = true
+		case "--details":
+			if i+1 >= len(args) {
+				return slashCommand{}, true, fmt.Errorf("%s: --details requires a value", slashCommandName)
+			}
+			i++
+			cmd.details = args[i]
+		default:
+			return slashCommand{}, true, fmt.Errorf("%s: unknown argument %q", slashCommandName, args[i])
+		}
+	}
+	return cmd, true, nil
+}
+
+// splitArgs splits the line by spaces keeping double-quoted arguments whole.
+func splitArgs(line string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quoted  bool
+		started bool
+	)
+
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case r == ' ' && !quoted:
+			if started {
+				args = append(args, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if started {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlashCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected slashCommand
+		isCmd    bool
+		wantErr  bool
+	}{
+		{
+			name:  "plain",
+			body:  "/auto-label",
+			isCmd: true,
+		},
+		{
+			name:     "dry run",
+			body:     "  /auto-label dry-run\nthanks",
+			expected: slashCommand{dryRun: true},
+			isCmd:    true,
+		},
+		{
+			name:     "details",
+			body:     `/auto-label --details "It is about the CLI" dry-run`,
+			expected: slashCommand{details: "It is about the CLI", dryRun: true},
+			isCmd:    true,
+		},
+		{
+			name: "not a command",
+			body: "Please run /auto-label",
+		},
+		{
+			name: "other command",
+			body: "/auto-labeler",
+		},
+		{
+			name:    "missing details",
+			body:    "/auto-label --details",
+			isCmd:   true,
+			wantErr: true,
+		},
+		{
+			name:    "unknown argument",
+			body:    "/auto-label now",
+			isCmd:   true,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			body:    `/auto-label --details "CLI`,
+			isCmd:   true,
+			wantErr: true,
+		},
+		{
+			name: "unterminated quote in a comment",
+			body: `It says "unexpected token`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, isCmd, err := parseSlashCommand(tt.body)
+			assert.Equal(t, tt.isCmd, isCmd)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cmd)
+		})
+	}
+}
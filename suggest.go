@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// modeApply applies the chosen labels right away.
+	modeApply = "apply"
+	// modeSuggest only lists the chosen labels in the comment for maintainers to approve.
+	modeSuggest = "suggest"
+)
+
+var checkboxRegexp = regexp.MustCompile(`(?m)^- \[([ xX])\] \*\*(.+?)\*\*`)
+
+// checkedLabels returns the names of the suggested labels with ticked boxes.
+func checkedLabels(body string) []string {
+	var checked []string
+	for _, m := range checkboxRegexp.FindAllStringSubmatch(body, -1) {
+		if m[1] != " " {
+			checked = append(checked, m[2])
+		}
+	}
+	return checked
+}
+
+// diffChecked returns the labels ticked and unticked by the edit of the comment.
+func diffChecked(before, after string) (ticked, unticked []string) {
+	was, is := checkedLabels(before), checkedLabels(after)
+	for _, l := range is {
+		if !slices.Contains(was, l) {
+			ticked = append(ticked, l)
+		}
+	}
+	for _, l := range was {
+		if !slices.Contains(is, l) {
+			unticked = append(unticked, l)
+		}
+	}
+	return ticked, unticked
+}
+
+// tickAll ticks the boxes of all suggested labels.
+func tickAll(body string) string {
+	return checkboxRegexp.ReplaceAllString(body, "- [x] **$2**")
+}
+
+func labelIDsByName(labels []Label, names []string) []string {
+	var ids []string
+	for _, l := range labels {
+		if slices.Contains(names, l.Name) {
+			ids = append(ids, l.ID)
+		}
+	}
+	return ids
+}
+
+// handleSuggestionEdit applies the labels whose boxes a maintainer ticked
+// in the suggestion comment and removes those that were unticked.
+func handleSuggestionEdit(ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, p payload) error {
+	marker, ok := parseCommentMarker(p.comment.body)
+	if !ok || !marker.Suggest || !strings.EqualFold(p.comment.author, resolveBotLogin(ctx, ghapi)) {
+		log.Println("The edited comment is not a suggestion of the action.")
+		return nil
+	}
+
+	allowed, err := hasTriageAccess(ctx, cfg, ghapi, p.sender)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		log.Printf("User %q is not allowed to approve suggestions.", p.sender)
+		return nil
+	}
+
+	ticked, unticked := diffChecked(p.comment.previousBody, p.comment.body)
+	if len(ticked) == 0 && len(unticked) == 0 {
+		return nil
+	}
+
+	repoLabels, err := ghapi.FetchRepoLabels(ctx, cfg.repoOwner, cfg.repoName)
+	if err != nil {
+		return err
+	}
+
+	if ids := labelIDsByName(repoLabels, ticked); len(ids) > 0 {
+		log.Printf("Applying the labels approved by %s: %s", p.sender, strings.Join(ticked, ", "))
+		if err := ghapi.ReplaceLabels(ctx, p.nodeID, ids); err != nil {
+			return err
+		}
+	}
+
+	if ids := labelIDsByName(repoLabels, unticked); len(ids) > 0 {
+		log.Printf("Removing the labels unticked by %s: %s", p.sender, strings.Join(unticked, ", "))
+		if err := ghapi.RemoveLabels(ctx, p.nodeID, ids); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyApprovedSuggestion applies all suggested labels once a maintainer reacts with 👍 to the suggestion comment.
+// Reactions do not trigger workflows, so they are checked when a comment is added to the item.
+func applyApprovedSuggestion(ctx context.Context, cfg config, ghapi *GitHubGraphQLClient, p payload) error {
+	suggestion, err := ghapi.FetchBotComment(ctx, p.nodeID)
+	if err != nil {
+		return err
+	}
+
+	if suggestion == nil || !suggestion.Marker.Suggest || suggestion.Marker.Approved {
+		return nil
+	}
+
+	var approver string
+	for _, login := range suggestion.Approvers {
+		allowed, err := hasTriageAccess(ctx, cfg, ghapi, login)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			approver = login
+			break
+		}
+	}
+
+	if approver == "" {
+		return nil
+	}
+
+	repoLabels, err := ghapi.FetchRepoLabels(ctx, cfg.repoOwner, cfg.repoName)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Applying the labels approved by %s: %s", approver, strings.Join(suggestion.Marker.Labels, ", "))
+	if err := ghapi.ReplaceLabels(ctx, p.nodeID, labelIDsByName(repoLabels, suggestion.Marker.Labels)); err != nil {
+		return err
+	}
+
+	marker := suggestion.Marker
+	marker.Approved = true
+	body := markerRegexp.ReplaceAllLiteralString(tickAll(suggestion.Body), marker.String())
+
+	updateCommentFn := ghapi.UpdateComment
+	if objectName(cfg.eventName) == "discussion" {
+		updateCommentFn = ghapi.UpdateDiscussionComment
+	}
+
+	if err := updateCommentFn(ctx, suggestion.ID, body); err != nil {
+		return fmt.Errorf("failed to mark the suggestion as approved: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const suggestionBody = `The ChatGPT Auto Labeler suggests the following labels:
+- [x] **bug**: Found a bug in the code.
+- [ ] **area/cli**: The CLI is affected.
+- [X] **question**: A question.
+
+- [bug](https://github.com/owner/repo/labels/bug)
+`
+
+func TestCheckedLabels(t *testing.T) {
+	assert.Equal(t, []string{"bug", "question"}, checkedLabels(suggestionBody))
+	assert.Empty(t, checkedLabels("- [bug](https://github.com/owner/repo/labels/bug)"))
+}
+
+func TestDiffChecked(t *testing.T) {
+	before := "- [x] **bug**: a\n- [ ] **area/cli**: b\n- [x] **question**: c\n"
+	after := "- [ ] **bug**: a\n- [x] **area/cli**: b\n- [x] **question**: c\n"
+
+	ticked, unticked := diffChecked(before, after)
+	assert.Equal(t, []string{"area/cli"}, ticked)
+	assert.Equal(t, []string{"bug"}, unticked)
+}
+
+func TestTickAll(t *testing.T) {
+	assert.Equal(t, []string{"bug", "area/cli", "question"}, checkedLabels(tickAll(suggestionBody)))
+}
+
+func TestCreateCommentSuggest(t *testing.T) {
+	resp := getLabelsResponse{
+		Labels:      []chosenLabel{{ID: "1", Name: "bug", Explanation: "Found a bug."}},
+		Explanation: "explanation",
+	}
+
+	assert.Contains(t, createComment("issue", "owner", "repo", resp, true), "- [ ] **bug**: Found a bug.\n")
+	assert.Contains(t, createComment("issue", "owner", "repo", resp, false), "- **bug**: Found a bug.\n")
+}
+
+func TestHandleSuggestionEdit(t *testing.T) {
+	marker := commentMarker{Hash: "abc", Labels: []string{"bug", "area/cli"}, Suggest: true}
+	before := "- [ ] **bug**: a\n- [x] **area/cli**: b\n" + marker.String()
+	after := "- [x] **bug**: a\n- [ ] **area/cli**: b\n" + marker.String()
+
+	transport := &sequenceTransport{
+		responses: []string{
+			`{"data":{"viewer":{"login":"auto-labeler"}}}`,
+			`{"data":{"repository":{"collaborators":{"edges":[{"permission":"WRITE","node":{"login":"maintainer"}}]}}}}`,
+			`{"data":{"repository":{"labels":{"nodes":[{"id":"L_1","name":"bug"},{"id":"L_2","name":"area/cli"}]}}}}`,
+			`{"data":{}}`,
+		},
+	}
+	ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+	p := payload{
+		action: "edited",
+		nodeID: "I_1",
+		sender: "maintainer",
+		comment: &comment{
+			nodeID:       "IC_1",
+			author:       "auto-labeler",
+			body:         after,
+			previousBody: before,
+		},
+	}
+
+	err := handleSuggestionEdit(context.TODO(), config{repoOwner: "owner", repoName: "repo"}, ghapi, p)
+	require.NoError(t, err)
+
+	require.Len(t, transport.requests, 5)
+	assert.Contains(t, transport.requests[3], `addLabelsToLabelable(input:{labelableId: \"I_1\", labelIds: [\"L_1\"]})`)
+	assert.Contains(t, transport.requests[4], "removeLabelsFromLabelable")
+	assert.Contains(t, transport.requests[4], `"labelIds":["L_2"]`)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultLabelColor = "ededed"
+
+var labelColorRe = regexp.MustCompile(`^[0-9a-f]{6}$`)
+
+type syncAction string
+
+const (
+	syncCreate syncAction = "create"
+	syncUpdate syncAction = "update"
+	syncRename syncAction = "rename"
+	syncMerge  syncAction = "merge"
+	syncDelete syncAction = "delete"
+)
+
+// labelChange is a step of the plan that brings the repository labels to the labels file.
+type labelChange struct {
+	action syncAction
+	// existing is the label being updated, renamed or deleted.
+	existing Label
+	// spec is the label after the change. It is empty for deletions.
+	spec labelSpec
+	// into is the label the items of the merged label get before it is deleted.
+	into Label
+}
+
+func (c labelChange) String() string {
+	switch c.action {
+	case syncCreate:
+		return fmt.Sprintf("create %q (color %s, description %q)", c.spec.Name, c.spec.Color, c.spec.Description)
+	case syncRename:
+		return fmt.Sprintf("rename %q -> %q%s", c.existing.Name, c.spec.Name, c.fieldChanges())
+	case syncUpdate:
+		return fmt.Sprintf("update %q%s", c.existing.Name, c.fieldChanges())
+	case syncMerge:
+		// GitHub does not list discussions by label, so only issues and pull requests are relabeled
+		return fmt.Sprintf("merge %q into %q (warning: discussions lose %q)", c.existing.Name, c.spec.Name, c.existing.Name)
+	default:
+		return fmt.Sprintf("delete %q", c.existing.Name)
+	}
+}
+
+func (c labelChange) fieldChanges() string {
+	var changes string
+	if c.spec.Name != c.existing.Name && c.action == syncUpdate {
+		changes += fmt.Sprintf(", name %q -> %q", c.existing.Name, c.spec.Name)
+	}
+	if c.spec.Color != c.existing.Color {
+		changes += fmt.Sprintf(", color %s -> %s", c.existing.Color, c.spec.Color)
+	}
+	if c.spec.Description != c.existing.Description {
+		changes += fmt.Sprintf(", description %q -> %q", c.existing.Description, c.spec.Description)
+	}
+	return changes
+}
+
+func syncLabelsCommand(args []string) error {
+	fs := flag.NewFlagSet("sync-labels", flag.ExitOnError)
+	repo := fs.String("repo", "", "the repository in the owner/name format (default $GITHUB_REPOSITORY)")
+	labelsPath := fs.String("labels", ".github/labels.yml", "the path to the labels YAML")
+	dryRun := fs.Bool("dry-run", false, "print the plan without changing the labels")
+	prune := fs.Bool("delete", false, "delete the labels missing from the labels YAML")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	specs, err := loadLabelSpecs(*labelsPath)
+	if err != nil {
+		return err
+	}
+	if *prune && len(specs) == 0 {
+		return fmt.Errorf("refusing to delete all labels, %q lists no labels", *labelsPath)
+	}
+
+	var cfg config
+	if err := commandEnv(&cfg, *repo); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	ghapi := NewGithubClient(cfg.ghToken, cfg.graphQLEndpoint, nil)
+
+	labels, err := ghapi.FetchRepoLabels(ctx, cfg.repoOwner, cfg.repoName)
+	if err != nil {
+		return err
+	}
+
+	plan := planLabelSync(labels, specs, *prune)
+	if err := writePlan(os.Stdout, plan); err != nil {
+		return err
+	}
+
+	if *dryRun || len(plan) == 0 {
+		return nil
+	}
+	return applyLabelSync(ctx, ghapi, cfg, plan)
+}
+
+// loadLabelSpecs reads the labels YAML and normalizes the colors.
+func loadLabelSpecs(path string) ([]labelSpec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+
+	var specs []labelSpec
+	if err := yaml.Unmarshal(b, &specs); err != nil {
+		return nil, fmt.Errorf("failed to decode labels %q: %w", path, err)
+	}
+
+	if err := validateLabelSpecs(specs); err != nil {
+		return nil, fmt.Errorf("invalid labels %q: %w", path, err)
+	}
+	return specs, nil
+}
+
+// validateLabelSpecs checks that the names and the aliases do not repeat and the colors are hex codes.
+func validateLabelSpecs(specs []labelSpec) error {
+	seen := make(map[string]struct{})
+	for i := range specs {
+		s := &specs[i]
+		if s.Name == "" {
+			return errors.New("label without name")
+		}
+
+		s.Color = strings.ToLower(strings.TrimPrefix(s.Color, "#"))
+		if s.Color != "" && !labelColorRe.MatchString(s.Color) {
+			return fmt.Errorf("label %q has invalid color %q", s.Name, s.Color)
+		}
+
+		for _, name := range append([]string{s.Name}, s.Aliases...) {
+			key := strings.ToLower(name)
+			if _, exist := seen[key]; exist {
+				return fmt.Errorf("label %q is listed more than once", name)
+			}
+			seen[key] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// planLabelSync returns the changes that bring the labels to the specs. A label named as
+// an alias of a missing label is renamed, so that it stays applied to the same items.
+// The labels missing from the specs are deleted only if prune is set, and the aliases
+// of existing labels are merged into them, so that their items keep the label.
+func planLabelSync(labels []Label, specs []labelSpec, prune bool) []labelChange {
+	find := func(name string) int {
+		return slices.IndexFunc(labels, func(l Label) bool {
+			return strings.EqualFold(l.Name, name)
+		})
+	}
+
+	var (
+		plan    []labelChange
+		matched = make(map[int]struct{})
+		// targets are the existing labels the specs are applied to, by the spec name
+		targets = make(map[string]Label)
+	)
+	for _, s := range specs {
+		action := syncUpdate
+		i := find(s.Name)
+		if i < 0 {
+			action = syncRename
+			for _, alias := range s.Aliases {
+				if i = find(alias); i >= 0 {
+					break
+				}
+			}
+		}
+		if i < 0 {
+			if s.Color == "" {
+				s.Color = defaultLabelColor
+			}
+			plan = append(plan, labelChange{action: syncCreate, spec: s})
+			continue
+		}
+
+		matched[i] = struct{}{}
+		existing := labels[i]
+		target := existing
+		target.Name = s.Name
+		targets[s.Name] = target
+		if s.Color == "" {
+			s.Color = existing.Color
+		}
+
+		if action == syncUpdate && s.Name == existing.Name &&
+			strings.EqualFold(s.Color, existing.Color) && s.Description == existing.Description {
+			continue
+		}
+		plan = append(plan, labelChange{action: action, existing: existing, spec: s})
+	}
+
+	for i, l := range labels {
+		if _, ok := matched[i]; ok {
+			continue
+		}
+
+		spec, isAlias := aliasOf(specs, l.Name)
+		switch {
+		case isAlias && prune:
+			plan = append(plan, labelChange{action: syncMerge, existing: l, spec: spec, into: targets[spec.Name]})
+		case isAlias:
+			log.Printf("The label %q is an alias of another existing label and is kept, -delete merges it into %q.", l.Name, spec.Name)
+		case prune:
+			plan = append(plan, labelChange{action: syncDelete, existing: l})
+		}
+	}
+	return plan
+}
+
+// aliasOf returns the spec that lists the name as an alias.
+func aliasOf(specs []labelSpec, name string) (labelSpec, bool) {
+	idx := slices.IndexFunc(specs, func(s labelSpec) bool {
+		return slices.ContainsFunc(s.Aliases, func(alias string) bool {
+			return strings.EqualFold(alias, name)
+		})
+	})
+	if idx < 0 {
+		return labelSpec{}, false
+	}
+	return specs[idx], true
+}
+
+func writePlan(w io.Writer, plan []labelChange) error {
+	if len(plan) == 0 {
+		_, err := io.WriteString(w, "The labels are up to date.\n")
+		return err
+	}
+
+	var out strings.Builder
+	for _, c := range plan {
+		out.WriteString(c.String() + "\n")
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func applyLabelSync(ctx context.Context, ghapi *GitHubGraphQLClient, cfg config, plan []labelChange) error {
+	var repoID string
+	if slices.ContainsFunc(plan, func(c labelChange) bool { return c.action == syncCreate }) {
+		var err error
+		if repoID, err = ghapi.FetchRepositoryID(ctx, cfg.repoOwner, cfg.repoName); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range plan {
+		var err error
+		switch c.action {
+		case syncCreate:
+			err = ghapi.CreateLabel(ctx, repoID, c.spec.Name, c.spec.Color, c.spec.Description)
+		case syncUpdate, syncRename:
+			err = ghapi.UpdateLabel(ctx, c.existing.ID, c.spec.Name, c.spec.Color, c.spec.Description)
+		case syncMerge:
+			err = mergeLabel(ctx, ghapi, cfg, c.existing, c.into)
+		case syncDelete:
+			err = ghapi.DeleteLabel(ctx, c.existing.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Applied %d label changes.", len(plan))
+	return nil
+}
+
+// mergeLabel applies the label into to the items with the label and deletes it.
+func mergeLabel(ctx context.Context, ghapi *GitHubGraphQLClient, cfg config, label, into Label) error {
+	items, err := ghapi.FetchLabelItems(ctx, cfg.repoOwner, cfg.repoName, label.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range items {
+		if err := ghapi.ReplaceLabels(ctx, id, []string{into.ID}); err != nil {
+			return err
+		}
+	}
+	log.Printf("Labeled %d items of %q with %q.", len(items), label.Name, into.Name)
+
+	return ghapi.DeleteLabel(ctx, label.ID)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLabelSpecs(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "labels.yml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	t.Run("happy", func(t *testing.T) {
+		path := write(t, `
+- name: bug
+  color: "#D73A4A"
+  description: Something isn't working
+  aliases: [defect]
+- name: question
+`)
+		specs, err := loadLabelSpecs(path)
+		require.NoError(t, err)
+
+		expected := []labelSpec{
+			{Name: "bug", Color: "d73a4a", Description: "Something isn't working", Aliases: []string{"defect"}},
+			{Name: "question"},
+		}
+		assert.Equal(t, expected, specs)
+	})
+
+	t.Run("invalid color", func(t *testing.T) {
+		_, err := loadLabelSpecs(write(t, "- name: bug\n  color: red\n"))
+		require.Error(t, err)
+	})
+
+	t.Run("alias of another label", func(t *testing.T) {
+		_, err := loadLabelSpecs(write(t, "- name: bug\n- name: defect\n  aliases: [Bug]\n"))
+		require.Error(t, err)
+	})
+
+	t.Run("without name", func(t *testing.T) {
+		_, err := loadLabelSpecs(write(t, "- color: d73a4a\n"))
+		require.Error(t, err)
+	})
+}
+
+func TestPlanLabelSync(t *testing.T) {
+	labels := []Label{
+		{ID: "L_1", Name: "bug", Color: "d73a4a", Description: "Broken"},
+		{ID: "L_2", Name: "defect", Color: "ff0000"},
+		{ID: "L_3", Name: "question", Color: "d876e3"},
+		{ID: "L_4", Name: "old", Color: "ededed"},
+	}
+
+	tests := []struct {
+		name     string
+		specs    []labelSpec
+		prune    bool
+		expected []labelChange
+	}{
+		{
+			name:  "up to date",
+			specs: []labelSpec{{Name: "bug", Description: "Broken"}, {Name: "question", Color: "D876E3"}},
+		},
+		{
+			name:  "update and create",
+			specs: []labelSpec{{Name: "Bug", Description: "Broken"}, {Name: "question", Description: "Usage"}, {Name: "area/docs"}},
+			expected: []labelChange{
+				{action: syncUpdate, existing: labels[0], spec: labelSpec{Name: "Bug", Color: "d73a4a", Description: "Broken"}},
+				{action: syncUpdate, existing: labels[2], spec: labelSpec{Name: "question", Color: "d876e3", Description: "Usage"}},
+				{action: syncCreate, spec: labelSpec{Name: "area/docs", Color: defaultLabelColor}},
+			},
+		},
+		{
+			name:  "rename",
+			specs: []labelSpec{{Name: "kind/bug", Aliases: []string{"missing", "bug"}}},
+			expected: []labelChange{
+				{action: syncRename, existing: labels[0], spec: labelSpec{Name: "kind/bug", Color: "d73a4a", Aliases: []string{"missing", "bug"}}},
+			},
+		},
+		{
+			name:  "alias of an existing label is kept",
+			specs: []labelSpec{{Name: "bug", Description: "Broken", Aliases: []string{"defect"}}},
+		},
+		{
+			name:  "prune",
+			specs: []labelSpec{{Name: "bug", Description: "Broken", Aliases: []string{"defect"}}, {Name: "question"}},
+			prune: true,
+			expected: []labelChange{
+				{action: syncMerge, existing: labels[1], spec: labelSpec{Name: "bug", Description: "Broken", Aliases: []string{"defect"}}, into: labels[0]},
+				{action: syncDelete, existing: labels[3]},
+			},
+		},
+		{
+			name:  "merge into renamed",
+			specs: []labelSpec{{Name: "kind/bug", Aliases: []string{"bug", "defect"}}, {Name: "question"}, {Name: "old"}},
+			prune: true,
+			expected: []labelChange{
+				{action: syncRename, existing: labels[0], spec: labelSpec{Name: "kind/bug", Color: "d73a4a", Aliases: []string{"bug", "defect"}}},
+				{
+					action:   syncMerge,
+					existing: labels[1],
+					spec:     labelSpec{Name: "kind/bug", Aliases: []string{"bug", "defect"}},
+					into:     Label{ID: "L_1", Name: "kind/bug", Color: "d73a4a", Description: "Broken"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, planLabelSync(labels, tt.specs, tt.prune))
+		})
+	}
+}
+
+func TestWritePlan(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, writePlan(&out, nil))
+	assert.Equal(t, "The labels are up to date.\n", out.String())
+
+	plan := []labelChange{
+		{action: syncCreate, spec: labelSpec{Name: "area/docs", Color: "ededed", Description: "Docs"}},
+		{action: syncUpdate, existing: Label{Name: "bug", Color: "d73a4a"}, spec: labelSpec{Name: "Bug", Color: "d73a4a", Description: "Broken"}},
+		{action: syncRename, existing: Label{Name: "defect", Color: "d73a4a"}, spec: labelSpec{Name: "kind/bug", Color: "ff0000"}},
+		{action: syncMerge, existing: Label{Name: "broken"}, spec: labelSpec{Name: "kind/bug"}},
+		{action: syncDelete, existing: Label{Name: "old"}},
+	}
+
+	out.Reset()
+	require.NoError(t, writePlan(&out, plan))
+
+	expected := `create "area/docs" (color ededed, description "Docs")
+update "bug", name "bug" -> "Bug", description "" -> "Broken"
+rename "defect" -> "kind/bug", color d73a4a -> ff0000
+merge "broken" into "kind/bug" (warning: discussions lose "broken")
+delete "old"
+`
+	assert.Equal(t, expected, out.String())
+}
+
+func TestApplyLabelSync(t *testing.T) {
+	transport := &sequenceTransport{
+		responses: []string{
+			`{"data":{"repository":{"id":"R_1"}}}`,
+			`{"data":{"createLabel":{"clientMutationId":null}}}`,
+			`{"data":{"updateLabel":{"clientMutationId":null}}}`,
+			`{"data":{"repository":{"label":{"items":{"pageInfo":{"hasNextPage":false},"nodes":[{"id":"I_1"}]}}}}}`,
+			`{"data":{"repository":{"label":{"items":{"pageInfo":{"hasNextPage":false},"nodes":[]}}}}}`,
+			`{"data":{"addLabelsToLabelable":{"clientMutationId":null}}}`,
+			`{"data":{"deleteLabel":{"clientMutationId":null}}}`,
+		},
+	}
+	ghapi := NewGithubClient("token", "url", &http.Client{Transport: transport})
+
+	plan := []labelChange{
+		{action: syncCreate, spec: labelSpec{Name: "area/docs", Color: "ededed"}},
+		{action: syncRename, existing: Label{ID: "L_2", Name: "defect"}, spec: labelSpec{Name: "bug", Color: "d73a4a"}},
+		{action: syncMerge, existing: Label{ID: "L_3", Name: "broken"}, spec: labelSpec{Name: "bug"}, into: Label{ID: "L_2", Name: "bug"}},
+		{action: syncDelete, existing: Label{ID: "L_4", Name: "old"}},
+	}
+
+	cfg := config{repoOwner: "owner", repoName: "repo"}
+	require.NoError(t, applyLabelSync(context.TODO(), ghapi, cfg, plan))
+
+	require.Len(t, transport.requests, 8)
+	assert.Contains(t, transport.requests[1], `"repositoryId":"R_1"`)
+	assert.Contains(t, transport.requests[1], `"name":"area/docs"`)
+	assert.Contains(t, transport.requests[2], `"id":"L_2"`)
+	assert.Contains(t, transport.requests[2], `"name":"bug"`)
+	assert.Contains(t, transport.requests[3], `"label":"broken"`)
+	assert.Contains(t, transport.requests[3], "issues(")
+	assert.Contains(t, transport.requests[4], "pullRequests(")
+	assert.Contains(t, transport.requests[5], `I_1`)
+	assert.Contains(t, transport.requests[5], `L_2`)
+	assert.Contains(t, transport.requests[6], `"id":"L_3"`)
+	assert.Contains(t, transport.requests[7], `"id":"L_4"`)
+}
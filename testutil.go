@@ -17,3 +17,28 @@ func (t *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		Body:       io.NopCloser(strings.NewReader(t.response)),
 	}, nil
 }
+
+// sequenceTransport replies with the responses in order, repeating the last one,
+// and records the bodies of the received requests.
+type sequenceTransport struct {
+	responses []string
+	requests  []string
+	calls     int
+}
+
+func (t *sequenceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		t.requests = append(t.requests, string(b))
+	}
+
+	t.calls++
+	resp := t.responses[min(t.calls, len(t.responses))-1]
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(resp)),
+	}, nil
+}
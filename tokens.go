@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// responseTokens is the number of tokens reserved for the model's answer.
+	responseTokens = 1024
+
+	// labelsShare is the percentage of the prompt budget that the list of labels may take.
+	labelsShare = 40
+
+	defaultContextWindow = 4096
+
+	// collapsedBlockLines is the number of lines kept at each end of a collapsed log excerpt.
+	collapsedBlockLines = 5
+
+	truncationMarker = "\n\n[... content truncated ...]\n\n"
+)
+
+// contextWindows maps model name prefixes to the size of their context window.
+// The longest matching prefix wins.
+var contextWindows = map[string]int{
+	openai.GPT3Dot5Turbo:     4096,
+	openai.GPT3Dot5Turbo1106: 16385,
+	openai.GPT3Dot5Turbo16K:  16385,
+	openai.GPT4:              8192,
+	openai.GPT432K:           32768,
+	openai.GPT4TurboPreview:  128000,
+	"gpt-4-0125-preview":     128000,
+	"gpt-4-turbo":            128000,
+	"gpt-4o":                 128000,
+}
+
+// modelPrice is the price in USD per 1000 tokens.
+type modelPrice struct {
+	prompt     float64
+	completion float64
+}
+
+// modelPrices maps model name prefixes to their prices. The longest matching prefix wins.
+var modelPrices = map[string]modelPrice{
+	openai.GPT3Dot5Turbo:     {prompt: 0.0015, completion: 0.002},
+	openai.GPT3Dot5Turbo1106: {prompt: 0.001, completion: 0.002},
+	openai.GPT3Dot5Turbo16K:  {prompt: 0.003, completion: 0.004},
+	openai.GPT4:              {prompt: 0.03, completion: 0.06},
+	openai.GPT432K:           {prompt: 0.06, completion: 0.12},
+	openai.GPT4TurboPreview:  {prompt: 0.01, completion: 0.03},
+	"gpt-4-0125-preview":     {prompt: 0.01, completion: 0.03},
+	"gpt-4-turbo":            {prompt: 0.01, completion: 0.03},
+	"gpt-4o":                 {prompt: 0.005, completion: 0.015},
+}
+
+// embeddingPrice is the price of the embedding model in USD per 1000 tokens.
+const embeddingPrice = 0.0001
+
+func contextWindow(model string) int {
+	if window, ok := lookupModel(contextWindows, model); ok {
+		return window
+	}
+	return defaultContextWindow
+}
+
+// cost returns the price of the request in USD or zero if the model price is unknown.
+func cost(model string, usage openai.Usage) float64 {
+	price, _ := lookupModel(modelPrices, model)
+	return (float64(usage.PromptTokens)*price.prompt + float64(usage.CompletionTokens)*price.completion) / 1000
+}
+
+// embeddingCost returns the price of embedding the tokens in USD.
+func embeddingCost(tokens int) float64 {
+	return float64(tokens) * embeddingPrice / 1000
+}
+
+func lookupModel[T any](m map[string]T, model string) (T, bool) {
+	var (
+		value   T
+		found   bool
+		longest int
+	)
+
+	for prefix, v := range m {
+		if strings.HasPrefix(model, prefix) && len(prefix) > longest {
+			value, found = v, true
+			longest = len(prefix)
+		}
+	}
+	return value, found
+}
+
+// countTokens returns the number of tokens in the text for the configured model.
+// Until useModelTokenizer is called, the number is estimated.
+var countTokens = estimateTokens
+
+// useModelTokenizer makes countTokens use the encoding of the model. The encodings are
+// embedded in the binary, so nothing is downloaded. For models with an unknown
+// encoding the number of tokens is estimated.
+func useModelTokenizer(model string) {
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+
+	enc, err := tiktoken.EncodingForModel(model)
+	if err != nil {
+		log.Printf("The encoding of the model %q is unknown, the number of tokens is estimated.", model)
+		countTokens = estimateTokens
+		return
+	}
+
+	countTokens = func(text string) int {
+		return len(enc.EncodeOrdinary(text))
+	}
+}
+
+// estimateTokens estimates the number of tokens in the text the way OpenAI tokenizers
+// split it: runs of ASCII letters and digits take roughly one token per four characters,
+// while punctuation and every non-ASCII character take about one token each.
+func estimateTokens(text string) int {
+	var tokens, word int
+
+	flush := func() {
+		tokens += (word + 3) / 4
+		word = 0
+	}
+
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// tokenBudget splits the tokens available for the prompt between
+// the system prompt, the list of labels and the body of the item.
+type tokenBudget struct {
+	total int
+}
+
+// newTokenBudget returns a budget for the model. If limit is positive,
+// the budget does not exceed it.
+func newTokenBudget(model string, limit int) tokenBudget {
+	total := contextWindow(model) - responseTokens
+	if limit > 0 && limit < total {
+		total = limit
+	}
+	return tokenBudget{total: total}
+}
+
+// labels returns the maximum number of tokens that the list of labels may take.
+func (b tokenBudget) labels() int {
+	return b.total * labelsShare / 100
+}
+
+// body returns the number of tokens left for the body after the system prompt.
+// If nothing is left, the body is omitted with a warning.
+func (b tokenBudget) body(systemPrompt string) int {
+	left := b.total - countTokens(systemPrompt)
+	if left <= 0 {
+		log.Printf("The instructions take the whole prompt budget of %d tokens, the content is omitted. Increase -max-prompt-tokens.", b.total)
+		return 0
+	}
+	return left
+}
+
+// truncateText fits the text into the budget. Long fenced code blocks,
+// which usually contain pasted logs, are collapsed first. If that is not enough,
+// only the head and the tail of the text are kept.
+func truncateText(text string, budget int) (string, bool) {
+	if countTokens(text) <= budget {
+		return text, false
+	}
+
+	text = collapseCodeBlocks(text)
+	if countTokens(text) <= budget {
+		return text, true
+	}
+
+	budget -= countTokens(truncationMarker)
+	if budget <= 0 {
+		return "", true
+	}
+
+	headBudget := budget * 2 / 3
+	head := takeTokens(text, headBudget, false)
+	tail := takeTokens(text, budget-headBudget, true)
+
+	return head + truncationMarker + tail, true
+}
+
+// takeTokens returns the longest run of whole lines from the beginning (or the end)
+// of the text that fits into the budget.
+func takeTokens(text string, budget int, fromEnd bool) string {
+	lines := strings.SplitAfter(text, "\n")
+	if fromEnd {
+		slices.Reverse(lines)
+	}
+
+	var (
+		taken []string
+		used  int
+	)
+	for _, line := range lines {
+		n := countTokens(line)
+		if used+n > budget {
+			if len(taken) == 0 {
+				// a single line does not fit, so cut it
+				taken = append(taken, cutRunes(line, budget, fromEnd))
+			}
+			break
+		}
+		taken = append(taken, line)
+		used += n
+	}
+
+	if fromEnd {
+		slices.Reverse(taken)
+	}
+	return strings.Join(taken, "")
+}
+
+// cutRunes returns the longest prefix (or suffix) of the line that fits into the budget.
+func cutRunes(line string, budget int, fromEnd bool) string {
+	runes := []rune(line)
+	part := func(n int) string {
+		if fromEnd {
+			return string(runes[len(runes)-n:])
+		}
+		return string(runes[:n])
+	}
+
+	n := sort.Search(len(runes)+1, func(n int) bool {
+		return countTokens(part(n)) > budget
+	})
+	return part(max(n-1, 0))
+}
+
+// collapseCodeBlocks keeps only the first and last lines of long fenced code blocks.
+func collapseCodeBlocks(text string) string {
+	var (
+		out     []string
+		block   []string
+		inBlock bool
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		isFence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		switch {
+		case isFence && !inBlock:
+			inBlock = true
+			out = append(out, line)
+		case isFence && inBlock:
+			inBlock = false
+			out = append(out, collapseLines(block)...)
+			out = append(out, line)
+			block = nil
+		case inBlock:
+			block = append(block, line)
+		default:
+			out = append(out, line)
+		}
+	}
+
+	// unterminated block
+	out = append(out, collapseLines(block)...)
+
+	return strings.Join(out, "\n")
+}
+
+func collapseLines(lines []string) []string {
+	if len(lines) <= collapsedBlockLines*2+1 {
+		return lines
+	}
+
+	omitted := len(lines) - collapsedBlockLines*2
+	collapsed := append([]string{}, lines[:collapsedBlockLines]...)
+	collapsed = append(collapsed, fmt.Sprintf("... [%d lines omitted] ...", omitted))
+	return append(collapsed, lines[len(lines)-collapsedBlockLines:]...)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model    string
+		expected int
+	}{
+		{model: openai.GPT3Dot5Turbo, expected: 4096},
+		{model: openai.GPT3Dot5Turbo16K0613, expected: 16385},
+		{model: openai.GPT40613, expected: 8192},
+		{model: openai.GPT432K0613, expected: 32768},
+		{model: openai.GPT4TurboPreview, expected: 128000},
+		{model: "unknown", expected: defaultContextWindow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			assert.Equal(t, tt.expected, contextWindow(tt.model))
+		})
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{name: "empty", text: "", expected: 0},
+		{name: "words", text: "Hello world", expected: 4},
+		{name: "punctuation", text: "bug: crash!", expected: 5},
+		{name: "non-ASCII", text: "ошибка", expected: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, estimateTokens(tt.text))
+		})
+	}
+}
+
+func TestUseModelTokenizer(t *testing.T) {
+	t.Cleanup(func() { countTokens = estimateTokens })
+
+	useModelTokenizer(openai.GPT3Dot5Turbo)
+	assert.Equal(t, 2, countTokens("Hello world"))
+
+	useModelTokenizer("unknown")
+	assert.Equal(t, estimateTokens("Hello world"), countTokens("Hello world"))
+}
+
+func TestTokenBudgetBody(t *testing.T) {
+	b := tokenBudget{total: 10}
+	assert.Equal(t, 6, b.body("Hello world"))
+	assert.Zero(t, b.body(strings.Repeat("word ", 20)))
+}
+
+func TestTruncateText(t *testing.T) {
+	t.Run("fits", func(t *testing.T) {
+		text, truncated := truncateText("Title: Some title\nBody: Some body", 100)
+		assert.False(t, truncated)
+		assert.Equal(t, "Title: Some title\nBody: Some body", text)
+	})
+
+	t.Run("collapse logs", func(t *testing.T) {
+		var logs []string
+		for i := 0; i < 100; i++ {
+			logs = append(logs, fmt.Sprintf("line %d", i))
+		}
+		text := "Title: Crash\nBody: See logs\n```\n" + strings.Join(logs, "\n") + "\n```\nThanks"
+
+		truncated, ok := truncateText(text, 100)
+		assert.True(t, ok)
+		assert.Contains(t, truncated, "Title: Crash")
+		assert.Contains(t, truncated, "line 4\n... [90 lines omitted] ...\nline 95")
+		assert.Contains(t, truncated, "Thanks")
+		assert.LessOrEqual(t, countTokens(truncated), 100)
+	})
+
+	t.Run("head and tail", func(t *testing.T) {
+		var lines []string
+		for i := 0; i < 1000; i++ {
+			lines = append(lines, fmt.Sprintf("line %d", i))
+		}
+		text := "Title: Crash\n" + strings.Join(lines, "\n")
+
+		truncated, ok := truncateText(text, 100)
+		assert.True(t, ok)
+		assert.True(t, strings.HasPrefix(truncated, "Title: Crash\n"))
+		assert.True(t, strings.HasSuffix(truncated, "line 999"))
+		assert.Contains(t, truncated, truncationMarker)
+		assert.LessOrEqual(t, countTokens(truncated), 100)
+	})
+
+	t.Run("single long line", func(t *testing.T) {
+		text := strings.Repeat("word ", 1000)
+
+		truncated, ok := truncateText(text, 50)
+		assert.True(t, ok)
+		assert.NotEmpty(t, strings.TrimSpace(strings.ReplaceAll(truncated, truncationMarker, "")))
+		assert.LessOrEqual(t, countTokens(truncated), 50)
+	})
+}
+
+func TestCost(t *testing.T) {
+	usage := openai.Usage{PromptTokens: 1000, CompletionTokens: 500}
+
+	assert.InDelta(t, 0.06, cost(openai.GPT40613, usage), 1e-9)
+	assert.InDelta(t, 0.0025, cost(openai.GPT3Dot5Turbo, usage), 1e-9)
+	assert.Zero(t, cost("unknown", usage))
+}
+
+func TestEmbeddingCost(t *testing.T) {
+	assert.InDelta(t, 0.0001, embeddingCost(1000), 1e-9)
+}